@@ -0,0 +1,164 @@
+package houdeyun
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/funstory-ai/gobun/internal"
+)
+
+const (
+	// DefaultBaseURL is the HouDeYun open API endpoint
+	DefaultBaseURL = "https://api.houdeyun.cn/openapi/v1"
+
+	// DefaultTimeout bounds a single request when the caller's context has no deadline
+	DefaultTimeout = 60 * time.Second
+)
+
+// SSHInfo is the SSH access of an instance
+type SSHInfo struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	User     string `json:"user"`
+	Password string `json:"password"`
+}
+
+// Instance is an instance as returned by the HouDeYun open API
+type Instance struct {
+	ID           string  `json:"instance_id"`
+	Name         string  `json:"instance_name"`
+	RegionName   string  `json:"region_name"`
+	GPUType      string  `json:"gpu_type"`
+	GPUNum       int     `json:"gpu_num"`
+	CPUType      string  `json:"cpu_type"`
+	CPUCores     int     `json:"cpu_cores"`
+	MemoryGB     int64   `json:"memory_gb"`
+	SystemDiskGB int64   `json:"system_disk_gb"`
+	DataDiskGB   int64   `json:"data_disk_gb"`
+	DataDiskPath string  `json:"data_disk_path"`
+	Status       string  `json:"status"`
+	PriceHour    float64 `json:"price_hour"`
+	ImageID      string  `json:"image_id"`
+	SSH          SSHInfo `json:"ssh"`
+	CreatedAt    int64   `json:"created_at"`
+}
+
+// GPU is the stock and price of a GPU type
+type GPU struct {
+	GPUType   string  `json:"gpu_type"`
+	Stock     int     `json:"stock"`
+	PriceHour float64 `json:"price_hour"`
+}
+
+// Response is the envelope of every HouDeYun open API response
+type Response struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// errorKind maps a response code onto the errors of package internal, the
+// code is the HTTP status followed by a two digit detail, e.g. 40901
+func errorKind(status int, code int) error {
+	switch {
+	case code == 40901:
+		return internal.ErrOutOfStock
+	case code == 40201:
+		return internal.ErrInsufficientBalance
+	case code/100 == 401 || code/100 == 403:
+		return internal.ErrUnauthorized
+	case code/100 == 404:
+		return internal.ErrPodNotFound
+	case code/100 == 429 || status == 429:
+		return internal.ErrRateLimited
+	case code/100 >= 500 || status >= 500:
+		return internal.ErrTransient
+	default:
+		return nil
+	}
+}
+
+type API struct {
+	token   string
+	baseURL string
+	client  *http.Client
+}
+
+func InitAPI(token string, baseURL string) *API {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &API{
+		token:   token,
+		baseURL: baseURL,
+		client:  &http.Client{},
+	}
+}
+
+// DoRequest sends a request and decodes the data of the response envelope into out,
+// a non-zero envelope code or a non-2xx HTTP status is returned as an error.
+// Failures to reach the server are wrapped in internal.ErrTransient.
+func (api *API) DoRequest(ctx context.Context, method string, path string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(bodyBytes)
+	}
+	reqCtx := ctx
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		reqCtx, cancel = context.WithTimeout(ctx, DefaultTimeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(reqCtx, method, api.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+api.token)
+	resp, err := api.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		return fmt.Errorf("%s %s: %w: %w", method, path, internal.ErrTransient, err)
+	}
+	defer resp.Body.Close()
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w: %w", method, path, internal.ErrTransient, err)
+	}
+
+	var response Response
+	decodeErr := json.Unmarshal(bodyBytes, &response)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		// Gateways answer errors without an envelope, classify them by
+		// their HTTP status alone
+		if decodeErr != nil || response.Code == 0 {
+			if kind := errorKind(resp.StatusCode, resp.StatusCode*100); kind != nil {
+				return fmt.Errorf("%s %s: %w: http status: %d", method, path, kind, resp.StatusCode)
+			}
+			return fmt.Errorf("%s %s: http status: %d", method, path, resp.StatusCode)
+		}
+	} else if decodeErr != nil {
+		return fmt.Errorf("%s %s: decoding response with http status %d failed: %w", method, path, resp.StatusCode, decodeErr)
+	}
+	if response.Code != 0 {
+		if kind := errorKind(resp.StatusCode, response.Code); kind != nil {
+			return fmt.Errorf("%s %s: %w: response code: %d %s", method, path, kind, response.Code, response.Message)
+		}
+		return fmt.Errorf("%s %s: response code: %d %s", method, path, response.Code, response.Message)
+	}
+	if out == nil || len(response.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(response.Data, out)
+}
@@ -0,0 +1,248 @@
+// Package houdeyuntest provides an in-memory fake of the HouDeYun open API,
+// so that the houdeyun adaptor can be exercised without network access.
+package houdeyuntest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/funstory-ai/gobun/adaptors/houdeyun"
+)
+
+const (
+	// Token is the only API token accepted by the fake server
+	Token = "fake-token"
+
+	codeOK           = 0
+	codeUnauthorized = 40100
+	codeNotFound     = 40400
+	codeOutOfStock   = 40901
+	codeBadRequest   = 40000
+)
+
+// Server is a fake HouDeYun open API server. Instances start in the
+// "starting" status and become "running" the next time they are fetched.
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	nextID    int
+	instances map[string]*houdeyun.Instance
+	// stock is the number of GPUs left per GPU type
+	stock map[string]int
+	// prices is the hourly price of one GPU per GPU type
+	prices map[string]float64
+}
+
+// NewServer starts a fake server with some stock of every supported GPU,
+// the caller must Close it
+func NewServer() *Server {
+	s := &Server{
+		instances: make(map[string]*houdeyun.Instance),
+		stock: map[string]int{
+			"GeForce RTX 4090":  8,
+			"GeForce RTX 4090D": 8,
+			"GeForce RTX 3090":  8,
+			"A100-SXM4-80GB":    2,
+		},
+		prices: map[string]float64{
+			"GeForce RTX 4090":  1.98,
+			"GeForce RTX 4090D": 1.88,
+			"GeForce RTX 3090":  1.28,
+			"A100-SXM4-80GB":    6.68,
+		},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// SetStock sets the number of GPUs left of a GPU type
+func (s *Server) SetStock(gpuType string, count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stock[gpuType] = count
+}
+
+// Instances returns a snapshot of all instances
+func (s *Server) Instances() []houdeyun.Instance {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	instances := make([]houdeyun.Instance, 0, len(s.instances))
+	for _, instance := range s.instances {
+		instances = append(instances, *instance)
+	}
+	return instances
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+Token {
+		writeResponse(w, http.StatusUnauthorized, codeUnauthorized, "invalid token", nil)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "gpus" && r.Method == http.MethodGet:
+		s.listGPUs(w)
+	case len(parts) == 1 && parts[0] == "instances" && r.Method == http.MethodGet:
+		s.listInstances(w)
+	case len(parts) == 1 && parts[0] == "instances" && r.Method == http.MethodPost:
+		s.createInstance(w, r)
+	case len(parts) == 2 && parts[0] == "instances" && r.Method == http.MethodGet:
+		s.getInstance(w, parts[1])
+	case len(parts) == 2 && parts[0] == "instances" && r.Method == http.MethodDelete:
+		s.deleteInstance(w, parts[1])
+	case len(parts) == 3 && parts[0] == "instances" && r.Method == http.MethodPost:
+		s.instanceAction(w, parts[1], parts[2])
+	default:
+		writeResponse(w, http.StatusNotFound, codeNotFound, "no such endpoint", nil)
+	}
+}
+
+func (s *Server) listGPUs(w http.ResponseWriter) {
+	gpus := make([]houdeyun.GPU, 0, len(s.stock))
+	for gpuType, stock := range s.stock {
+		gpus = append(gpus, houdeyun.GPU{
+			GPUType:   gpuType,
+			Stock:     stock,
+			PriceHour: s.prices[gpuType],
+		})
+	}
+	writeResponse(w, http.StatusOK, codeOK, "ok", map[string]interface{}{
+		"gpus": gpus,
+	})
+}
+
+func (s *Server) listInstances(w http.ResponseWriter) {
+	instances := make([]houdeyun.Instance, 0, len(s.instances))
+	for _, instance := range s.instances {
+		instances = append(instances, *instance)
+	}
+	writeResponse(w, http.StatusOK, codeOK, "ok", map[string]interface{}{
+		"instances": instances,
+	})
+}
+
+func (s *Server) createInstance(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		GPUType string `json:"gpu_type"`
+		GPUNum  int    `json:"gpu_num"`
+		ImageID string `json:"image_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.GPUNum < 1 {
+		writeResponse(w, http.StatusBadRequest, codeBadRequest, "invalid request", nil)
+		return
+	}
+	if s.stock[request.GPUType] < request.GPUNum {
+		writeResponse(w, http.StatusOK, codeOutOfStock, "insufficient gpu stock", nil)
+		return
+	}
+	s.stock[request.GPUType] -= request.GPUNum
+
+	s.nextID++
+	id := fmt.Sprintf("hdy-%04d", s.nextID)
+	s.instances[id] = &houdeyun.Instance{
+		ID:           id,
+		Name:         id,
+		RegionName:   "华东一区",
+		GPUType:      request.GPUType,
+		GPUNum:       request.GPUNum,
+		CPUType:      "Intel Xeon Platinum 8352V",
+		CPUCores:     16 * request.GPUNum,
+		MemoryGB:     64 * int64(request.GPUNum),
+		SystemDiskGB: 30,
+		DataDiskGB:   50,
+		DataDiskPath: "/root/data",
+		Status:       "starting",
+		PriceHour:    s.prices[request.GPUType] * float64(request.GPUNum),
+		ImageID:      request.ImageID,
+		SSH: houdeyun.SSHInfo{
+			Host:     "ssh.houdeyun.example",
+			Port:     20000 + s.nextID,
+			User:     "root",
+			Password: "fake-password",
+		},
+		CreatedAt: time.Now().Unix(),
+	}
+	writeResponse(w, http.StatusOK, codeOK, "ok", map[string]string{
+		"instance_id": id,
+	})
+}
+
+func (s *Server) getInstance(w http.ResponseWriter, id string) {
+	instance, ok := s.instances[id]
+	if !ok {
+		writeResponse(w, http.StatusNotFound, codeNotFound, "instance not found", nil)
+		return
+	}
+	response := *instance
+	if instance.Status == "starting" {
+		instance.Status = "running"
+	}
+	writeResponse(w, http.StatusOK, codeOK, "ok", response)
+}
+
+func (s *Server) deleteInstance(w http.ResponseWriter, id string) {
+	instance, ok := s.instances[id]
+	if !ok {
+		writeResponse(w, http.StatusNotFound, codeNotFound, "instance not found", nil)
+		return
+	}
+	if instance.Status != "stopped" {
+		s.stock[instance.GPUType] += instance.GPUNum
+	}
+	delete(s.instances, id)
+	writeResponse(w, http.StatusOK, codeOK, "ok", nil)
+}
+
+func (s *Server) instanceAction(w http.ResponseWriter, id string, action string) {
+	instance, ok := s.instances[id]
+	if !ok {
+		writeResponse(w, http.StatusNotFound, codeNotFound, "instance not found", nil)
+		return
+	}
+	switch action {
+	case "stop":
+		if instance.Status != "stopped" {
+			s.stock[instance.GPUType] += instance.GPUNum
+		}
+		instance.Status = "stopped"
+	case "start":
+		if instance.Status == "stopped" {
+			if s.stock[instance.GPUType] < instance.GPUNum {
+				writeResponse(w, http.StatusOK, codeOutOfStock, "insufficient gpu stock", nil)
+				return
+			}
+			s.stock[instance.GPUType] -= instance.GPUNum
+		}
+		instance.Status = "starting"
+	case "restart":
+		if instance.Status != "running" {
+			writeResponse(w, http.StatusOK, codeBadRequest, "instance is not running", nil)
+			return
+		}
+		instance.Status = "starting"
+	default:
+		writeResponse(w, http.StatusNotFound, codeNotFound, "no such endpoint", nil)
+		return
+	}
+	writeResponse(w, http.StatusOK, codeOK, "ok", nil)
+}
+
+func writeResponse(w http.ResponseWriter, status int, code int, message string, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"code":    code,
+		"message": message,
+		"data":    data,
+	})
+}
@@ -0,0 +1,228 @@
+package houdeyun
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+
+	"github.com/funstory-ai/gobun/internal"
+)
+
+const (
+	PoolID = "houdeyun"
+
+	// EnvToken is the environment variable holding the HouDeYun API token
+	EnvToken = "HDY_TOKEN"
+	// EnvBaseURL overrides the API endpoint, e.g. to point at a fake server
+	EnvBaseURL = "HDY_API_URL"
+
+	// defaultImageID is the public PyTorch image used for new instances
+	defaultImageID = "pytorch-2.4-cuda12.1"
+
+	gib = 1 << 30
+)
+
+func init() {
+	internal.RegisterPool(PoolID, newPoolFromEnv)
+}
+
+// newPoolFromEnv creates the pool with the API token from the environment
+func newPoolFromEnv() (internal.Pool, error) {
+	token := os.Getenv(EnvToken)
+	if token == "" {
+		return nil, fmt.Errorf("environment variable %s is not set", EnvToken)
+	}
+	return NewPool(token, os.Getenv(EnvBaseURL)), nil
+}
+
+type Pool struct {
+	api *API
+	id  string
+}
+
+// NewPool creates a HouDeYun pool, an empty baseURL uses DefaultBaseURL
+func NewPool(token string, baseURL string) *Pool {
+	return &Pool{
+		api: InitAPI(token, baseURL),
+		id:  PoolID,
+	}
+}
+
+func (p *Pool) ID() string {
+	return p.id
+}
+
+func (p *Pool) ListPods(ctx context.Context, options internal.ListOptions) ([]internal.Pod, error) {
+	var data struct {
+		Instances []Instance `json:"instances"`
+	}
+	if err := p.api.DoRequest(ctx, "GET", "/instances", nil, &data); err != nil {
+		return nil, err
+	}
+	pods := make([]internal.Pod, len(data.Instances))
+	for i, instance := range data.Instances {
+		pods[i] = p.toPod(instance)
+	}
+	return internal.FilterPods(pods, options), nil
+}
+
+func (p *Pool) GetPod(ctx context.Context, id string) (internal.Pod, error) {
+	var instance Instance
+	if err := p.api.DoRequest(ctx, "GET", "/instances/"+url.PathEscape(id), nil, &instance); err != nil {
+		return internal.Pod{}, err
+	}
+	return p.toPod(instance), nil
+}
+
+// CheckPodOptions rejects a data center, the API places instances itself
+func (p *Pool) CheckPodOptions(options internal.PodOptions) error {
+	if options.DataCenter != "" {
+		return fmt.Errorf("pool %s cannot create pods in a chosen data center", p.id)
+	}
+	return nil
+}
+
+func (p *Pool) CreatePod(ctx context.Context, options internal.PodOptions) (internal.Pod, error) {
+	if err := internal.CheckPodOptions(p, options); err != nil {
+		return internal.Pod{}, err
+	}
+	gpuType, err := GPUModelMapping(options.GPUModel)
+	if err != nil {
+		return internal.Pod{}, err
+	}
+
+	imageID := defaultImageID
+	if options.Image != "" {
+		imageID = options.Image
+	}
+	payload := map[string]interface{}{
+		"gpu_type": gpuType,
+		"gpu_num":  options.GPUCount,
+		"image_id": imageID,
+	}
+	if options.Name != "" {
+		payload["name"] = options.Name
+	}
+	if options.DataDiskSize > 0 {
+		// the API takes whole GiB, round up so the disk is not too small
+		payload["data_disk_gb"] = (options.DataDiskSize + gib - 1) / gib
+	}
+	var data struct {
+		InstanceID string `json:"instance_id"`
+	}
+	if err := p.api.DoRequest(ctx, "POST", "/instances", payload, &data); err != nil {
+		return internal.Pod{}, fmt.Errorf("failed to create pod: %w", err)
+	}
+	if data.InstanceID == "" {
+		return internal.Pod{}, fmt.Errorf("failed to create pod: response has no instance_id")
+	}
+	return internal.GetCreatedPod(ctx, p, data.InstanceID)
+}
+
+func (p *Pool) DestroyPod(ctx context.Context, podID string) error {
+	return p.api.DoRequest(ctx, "DELETE", "/instances/"+url.PathEscape(podID), nil, nil)
+}
+
+func (p *Pool) StopPod(ctx context.Context, podID string) error {
+	return p.api.DoRequest(ctx, "POST", "/instances/"+url.PathEscape(podID)+"/stop", nil, nil)
+}
+
+func (p *Pool) StartPod(ctx context.Context, podID string) error {
+	return p.api.DoRequest(ctx, "POST", "/instances/"+url.PathEscape(podID)+"/start", nil, nil)
+}
+
+func (p *Pool) RestartPod(ctx context.Context, podID string) error {
+	return p.api.DoRequest(ctx, "POST", "/instances/"+url.PathEscape(podID)+"/restart", nil, nil)
+}
+
+// ListOffers returns the stock and price of every GPU type
+func (p *Pool) ListOffers(ctx context.Context) ([]internal.Offer, error) {
+	var data struct {
+		GPUs []GPU `json:"gpus"`
+	}
+	if err := p.api.DoRequest(ctx, "GET", "/gpus", nil, &data); err != nil {
+		return nil, err
+	}
+	offers := make([]internal.Offer, len(data.GPUs))
+	for i, gpu := range data.GPUs {
+		offers[i] = internal.Offer{
+			PoolID:       p.id,
+			GPUModel:     GPUModelFromProvider(gpu.GPUType),
+			Stock:        gpu.Stock,
+			PricePerHour: gpu.PriceHour,
+		}
+	}
+	return offers, nil
+}
+
+func (p *Pool) toPod(instance Instance) internal.Pod {
+	sshPort := ""
+	if instance.SSH.Port != 0 {
+		sshPort = strconv.Itoa(instance.SSH.Port)
+	}
+	return internal.Pod{
+		ID:                instance.ID,
+		PoolID:            p.id,
+		CreateTimestamp:   instance.CreatedAt,
+		DataCenterName:    instance.RegionName,
+		Name:              instance.Name,
+		GPUModel:          GPUModelFromProvider(instance.GPUType),
+		GPUCount:          instance.GPUNum,
+		CPUModel:          instance.CPUType,
+		CPUCoreCount:      instance.CPUCores,
+		MemorySize:        instance.MemoryGB * gib,
+		SystemDiskSize:    instance.SystemDiskGB * gib,
+		DataDiskSize:      instance.DataDiskGB * gib,
+		DataDiskMountPath: instance.DataDiskPath,
+		PricePerHour:      instance.PriceHour,
+		SSHDomain:         instance.SSH.Host,
+		SSHPort:           sshPort,
+		SSHUser:           instance.SSH.User,
+		Password:          instance.SSH.Password,
+		Status:            StatusMapping(instance.Status),
+		ImageID:           instance.ImageID,
+		Pool:              p,
+	}
+}
+
+// gpuNames are the HouDeYun GPU types of the GPU models
+var gpuNames = internal.NewGPUNames(map[internal.GPUModel]string{
+	internal.GPUModelRTX4090:   "GeForce RTX 4090",
+	internal.GPUModelRTX4090_D: "GeForce RTX 4090D",
+	internal.GPUModelRTX3090:   "GeForce RTX 3090",
+	internal.GPUModelA100_40G:  "A100-PCIE-40GB",
+	internal.GPUModelA100_80G:  "A100-SXM4-80GB",
+	internal.GPUModelA800_80G:  "A800-SXM4-80GB",
+})
+
+// GPUModelMapping returns the HouDeYun GPU type of a GPU model
+func GPUModelMapping(gpuModel internal.GPUModel) (string, error) {
+	return gpuNames.Name(gpuModel)
+}
+
+// GPUModelFromProvider returns the GPU model of a HouDeYun GPU type,
+// unknown types are kept as they are
+func GPUModelFromProvider(gpuType string) internal.GPUModel {
+	return gpuNames.Model(gpuType)
+}
+
+// StatusMapping returns the pod status of a HouDeYun instance status,
+// unknown statuses are kept as they are
+func StatusMapping(status string) internal.PodStatus {
+	switch status {
+	case "pending", "creating", "starting":
+		return internal.StatusCreating
+	case "running":
+		return internal.StatusRunning
+	case "stopping":
+		return internal.StatusStopping
+	case "stopped":
+		return internal.StatusStopped
+	case "failed":
+		return internal.StatusError
+	default:
+		return internal.PodStatus(status)
+	}
+}
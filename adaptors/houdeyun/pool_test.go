@@ -0,0 +1,223 @@
+package houdeyun_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/funstory-ai/gobun/adaptors/houdeyun"
+	"github.com/funstory-ai/gobun/adaptors/houdeyun/houdeyuntest"
+	"github.com/funstory-ai/gobun/internal"
+)
+
+func newTestPool(t *testing.T) (*houdeyun.Pool, *houdeyuntest.Server) {
+	t.Helper()
+	server := houdeyuntest.NewServer()
+	t.Cleanup(server.Close)
+	return houdeyun.NewPool(houdeyuntest.Token, server.URL), server
+}
+
+func TestPodLifecycle(t *testing.T) {
+	ctx := context.Background()
+	pool, server := newTestPool(t)
+
+	pod, err := pool.CreatePod(ctx, internal.PodOptions{GPUModel: internal.GPUModelRTX4090, GPUCount: 2})
+	if err != nil {
+		t.Fatalf("CreatePod: %v", err)
+	}
+	if pod.GPUModel != internal.GPUModelRTX4090 || pod.GPUCount != 2 {
+		t.Errorf("created pod has %d x %s, want 2 x %s", pod.GPUCount, pod.GPUModel, internal.GPUModelRTX4090)
+	}
+	if pod.PoolID != houdeyun.PoolID || pod.Pool != internal.Pool(pool) {
+		t.Errorf("created pod belongs to %q", pod.PoolID)
+	}
+	if pod.MemorySize != 128<<30 || pod.DataDiskSize != 50<<30 {
+		t.Errorf("sizes are not converted to bytes: memory %d, data disk %d", pod.MemorySize, pod.DataDiskSize)
+	}
+	if pod.SSHDomain == "" || pod.SSHPort == "" || pod.SSHUser != "root" {
+		t.Errorf("SSH access is missing: %s@%s:%s", pod.SSHUser, pod.SSHDomain, pod.SSHPort)
+	}
+
+	// The fake server reports "starting" once, then "running"
+	pod, err = pool.GetPod(ctx, pod.ID)
+	if err != nil {
+		t.Fatalf("GetPod: %v", err)
+	}
+	if pod.Status != internal.StatusRunning {
+		t.Errorf("status = %s, want %s", pod.Status, internal.StatusRunning)
+	}
+
+	pods, err := pool.ListPods(ctx, internal.ListOptions{})
+	if err != nil {
+		t.Fatalf("ListPods: %v", err)
+	}
+	if len(pods) != 1 || pods[0].ID != pod.ID {
+		t.Fatalf("ListPods = %v, want only %s", pods, pod.ID)
+	}
+	pods, err = pool.ListPods(ctx, internal.ListOptions{Statuses: []internal.PodStatus{internal.StatusStopped}})
+	if err != nil {
+		t.Fatalf("ListPods: %v", err)
+	}
+	if len(pods) != 0 {
+		t.Errorf("ListPods of stopped pods = %v, want none", pods)
+	}
+
+	if err := pool.StopPod(ctx, pod.ID); err != nil {
+		t.Fatalf("StopPod: %v", err)
+	}
+	if pod, err = pool.GetPod(ctx, pod.ID); err != nil || pod.Status != internal.StatusStopped {
+		t.Errorf("after StopPod status = %s, %v, want %s", pod.Status, err, internal.StatusStopped)
+	}
+	if err := pool.StartPod(ctx, pod.ID); err != nil {
+		t.Fatalf("StartPod: %v", err)
+	}
+	if pod, err = pool.GetPod(ctx, pod.ID); err != nil || pod.Status != internal.StatusCreating {
+		t.Errorf("after StartPod status = %s, %v, want %s", pod.Status, err, internal.StatusCreating)
+	}
+
+	if err := pool.DestroyPod(ctx, pod.ID); err != nil {
+		t.Fatalf("DestroyPod: %v", err)
+	}
+	if len(server.Instances()) != 0 {
+		t.Errorf("instances left after DestroyPod: %v", server.Instances())
+	}
+	if _, err := pool.GetPod(ctx, pod.ID); !errors.Is(err, internal.ErrPodNotFound) {
+		t.Errorf("GetPod of a destroyed pod = %v, want %v", err, internal.ErrPodNotFound)
+	}
+}
+
+func TestListOffers(t *testing.T) {
+	pool, _ := newTestPool(t)
+	offers, err := pool.ListOffers(context.Background())
+	if err != nil {
+		t.Fatalf("ListOffers: %v", err)
+	}
+	found := false
+	for _, offer := range offers {
+		if !offer.GPUModel.Known() {
+			t.Errorf("offer of unknown GPU model %q", offer.GPUModel)
+		}
+		if offer.GPUModel == internal.GPUModelA100_80G {
+			found = true
+			if offer.Stock != 2 || offer.PricePerHour != 6.68 {
+				t.Errorf("A100 offer = %+v", offer)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("no %s among %v", internal.GPUModelA100_80G, offers)
+	}
+}
+
+func TestStatusMapping(t *testing.T) {
+	tests := []struct {
+		status string
+		want   internal.PodStatus
+	}{
+		{"pending", internal.StatusCreating},
+		{"creating", internal.StatusCreating},
+		{"starting", internal.StatusCreating},
+		{"running", internal.StatusRunning},
+		{"stopping", internal.StatusStopping},
+		{"stopped", internal.StatusStopped},
+		{"failed", internal.StatusError},
+		{"migrating", internal.PodStatus("migrating")},
+	}
+	for _, tt := range tests {
+		if got := houdeyun.StatusMapping(tt.status); got != tt.want {
+			t.Errorf("StatusMapping(%q) = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestGPUModelMapping(t *testing.T) {
+	tests := []struct {
+		model   internal.GPUModel
+		gpuType string
+	}{
+		{internal.GPUModelRTX4090, "GeForce RTX 4090"},
+		{internal.GPUModelRTX4090_D, "GeForce RTX 4090D"},
+		{internal.GPUModelRTX3090, "GeForce RTX 3090"},
+		{internal.GPUModelA100_40G, "A100-PCIE-40GB"},
+		{internal.GPUModelA100_80G, "A100-SXM4-80GB"},
+		{internal.GPUModelA800_80G, "A800-SXM4-80GB"},
+	}
+	for _, tt := range tests {
+		gpuType, err := houdeyun.GPUModelMapping(tt.model)
+		if err != nil || gpuType != tt.gpuType {
+			t.Errorf("GPUModelMapping(%s) = %q, %v, want %q", tt.model, gpuType, err, tt.gpuType)
+		}
+		if model := houdeyun.GPUModelFromProvider(tt.gpuType); model != tt.model {
+			t.Errorf("GPUModelFromProvider(%q) = %s, want %s", tt.gpuType, model, tt.model)
+		}
+	}
+
+	if _, err := houdeyun.GPUModelMapping(internal.GPUModelH800_80G); err == nil {
+		t.Errorf("GPUModelMapping(%s) succeeded for a GPU HouDeYun does not offer", internal.GPUModelH800_80G)
+	}
+	if model := houdeyun.GPUModelFromProvider("Tesla T4"); model != "Tesla T4" {
+		t.Errorf("GPUModelFromProvider kept unknown type as %q", model)
+	}
+}
+
+func TestErrorMapping(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("out of stock", func(t *testing.T) {
+		pool, server := newTestPool(t)
+		server.SetStock("GeForce RTX 4090", 1)
+		_, err := pool.CreatePod(ctx, internal.PodOptions{GPUModel: internal.GPUModelRTX4090, GPUCount: 2})
+		if !errors.Is(err, internal.ErrOutOfStock) {
+			t.Errorf("CreatePod = %v, want %v", err, internal.ErrOutOfStock)
+		}
+		if len(server.Instances()) != 0 {
+			t.Errorf("an instance was created: %v", server.Instances())
+		}
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		server := houdeyuntest.NewServer()
+		defer server.Close()
+		pool := houdeyun.NewPool("wrong-token", server.URL)
+		_, err := pool.ListPods(ctx, internal.ListOptions{})
+		if !errors.Is(err, internal.ErrUnauthorized) {
+			t.Errorf("ListPods = %v, want %v", err, internal.ErrUnauthorized)
+		}
+	})
+
+	t.Run("gateway error without envelope", func(t *testing.T) {
+		gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "<html>502 Bad Gateway</html>", http.StatusBadGateway)
+		}))
+		defer gateway.Close()
+		pool := houdeyun.NewPool(houdeyuntest.Token, gateway.URL)
+		_, err := pool.ListPods(ctx, internal.ListOptions{})
+		if !errors.Is(err, internal.ErrTransient) {
+			t.Errorf("ListPods = %v, want %v", err, internal.ErrTransient)
+		}
+	})
+
+	t.Run("connection refused", func(t *testing.T) {
+		server := houdeyuntest.NewServer()
+		url := server.URL
+		server.Close()
+		pool := houdeyun.NewPool(houdeyuntest.Token, url)
+		_, err := pool.CreatePod(ctx, internal.PodOptions{GPUModel: internal.GPUModelRTX4090, GPUCount: 1})
+		if !errors.Is(err, internal.ErrTransient) || !internal.NothingCreated(err) {
+			t.Errorf("CreatePod = %v, want a transient dial error", err)
+		}
+	})
+}
+
+func TestCreatePodRejectsDataCenter(t *testing.T) {
+	pool, server := newTestPool(t)
+	options := internal.PodOptions{GPUModel: internal.GPUModelRTX4090, GPUCount: 1, DataCenter: "1"}
+	if _, err := pool.CreatePod(context.Background(), options); err == nil {
+		t.Fatal("CreatePod with a data center succeeded")
+	}
+	if len(server.Instances()) != 0 {
+		t.Errorf("an instance was created: %v", server.Instances())
+	}
+}
@@ -0,0 +1,345 @@
+// Package local implements a pool whose pods are working directories on
+// this machine served by an embedded SSH server. It needs no GPU cloud
+// account, so attach, exec, port forwarding and file sync can be developed
+// and tested end to end on a laptop. A pod is served as long as the process
+// that created it runs, e.g. for the duration of `gobun --pool local up`.
+// The pods left behind by processes that exited are found on disk and
+// listed as stopped, so they can be started again or destroyed.
+package local
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/funstory-ai/gobun/internal"
+	"github.com/funstory-ai/gobun/internal/utils/fileutil"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	PoolID = "local"
+
+	// EnvShell overrides the shell that runs commands in local pods
+	EnvShell = "GOBUN_LOCAL_SHELL"
+
+	// sshUser is the user name that local pods accept
+	sshUser = "gobun"
+)
+
+func init() {
+	internal.RegisterPool(PoolID, newPoolFromEnv)
+}
+
+// newPoolFromEnv creates the pool under the gobun cache directory
+func newPoolFromEnv() (internal.Pool, error) {
+	opts := Options{
+		Dir:   filepath.Join(fileutil.DefaultCacheDir, "local-pods"),
+		Shell: os.Getenv(EnvShell),
+	}
+	return NewPool(opts), nil
+}
+
+// Options configures the local pool
+type Options struct {
+	// Dir is where the working directories of the pods are created
+	Dir string
+	// Shell runs the commands and shells of the pods, defaults to /bin/sh
+	Shell string
+	// ListenHost is the host the SSH servers listen on, defaults to 127.0.0.1
+	ListenHost string
+}
+
+// localPod is a pod and the SSH server that serves it
+type localPod struct {
+	pod    internal.Pod
+	dir    string
+	server *server
+}
+
+type Pool struct {
+	id   string
+	opts Options
+
+	mu     sync.Mutex
+	nextID int
+	pods   map[string]*localPod
+}
+
+func NewPool(opts Options) *Pool {
+	if opts.Shell == "" {
+		opts.Shell = "/bin/sh"
+	}
+	if opts.ListenHost == "" {
+		opts.ListenHost = "127.0.0.1"
+	}
+	p := &Pool{
+		id:   PoolID,
+		opts: opts,
+		pods: make(map[string]*localPod),
+	}
+	p.adoptPods()
+	return p
+}
+
+// adoptPods adds the pods whose directories were left by gobun processes
+// that exited as stopped pods, the pods of running processes are theirs
+func (p *Pool) adoptPods() {
+	entries, err := os.ReadDir(p.opts.Dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		var pid, n int
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := fmt.Sscanf(entry.Name(), "local-%d-%d", &pid, &n); err != nil || processAlive(pid) {
+			continue
+		}
+		dir := filepath.Join(p.opts.Dir, entry.Name())
+		var created int64
+		if info, err := entry.Info(); err == nil {
+			created = info.ModTime().Unix()
+		}
+		p.pods[entry.Name()] = &localPod{
+			dir: dir,
+			pod: p.newPod(entry.Name(), entry.Name(), dir, created, internal.StatusStopped),
+		}
+	}
+}
+
+// processAlive tells whether a process with the given ID runs
+func processAlive(pid int) bool {
+	if pid == os.Getpid() {
+		return true
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, os.ErrPermission)
+}
+
+// newPod returns a pod served from dir, without SSH access until its
+// server is started
+func (p *Pool) newPod(id, name, dir string, created int64, status internal.PodStatus) internal.Pod {
+	return internal.Pod{
+		ID:                id,
+		PoolID:            p.id,
+		CreateTimestamp:   created,
+		DataCenterName:    "localhost",
+		Name:              name,
+		CPUModel:          runtime.GOARCH,
+		CPUCoreCount:      runtime.NumCPU(),
+		DataDiskMountPath: filepath.Join(dir, "data"),
+		Status:            status,
+		Pool:              p,
+	}
+}
+
+func (p *Pool) ID() string {
+	return p.id
+}
+
+// CheckPodOptions rejects the options that make no sense for a directory
+// on this machine
+func (p *Pool) CheckPodOptions(options internal.PodOptions) error {
+	if options.Image != "" || options.DataCenter != "" || options.DataDiskSize > 0 {
+		return fmt.Errorf("pool %s cannot create pods with an image, data center or data disk size", p.id)
+	}
+	return nil
+}
+
+func (p *Pool) CreatePod(ctx context.Context, options internal.PodOptions) (internal.Pod, error) {
+	if err := ctx.Err(); err != nil {
+		return internal.Pod{}, err
+	}
+	if err := internal.CheckPodOptions(p, options); err != nil {
+		return internal.Pod{}, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// a directory of this process ID may be left by an earlier process
+	var id, dir string
+	for {
+		p.nextID++
+		id = fmt.Sprintf("local-%d-%d", os.Getpid(), p.nextID)
+		dir = filepath.Join(p.opts.Dir, id)
+		if _, err := os.Stat(dir); err != nil {
+			break
+		}
+	}
+	name := id
+	if options.Name != "" {
+		name = options.Name
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "data"), 0700); err != nil {
+		return internal.Pod{}, fmt.Errorf("failed to create pod directory: %w", err)
+	}
+
+	lp := &localPod{
+		dir: dir,
+		pod: p.newPod(id, name, dir, time.Now().Unix(), internal.StatusCreating),
+	}
+	if err := p.boot(lp); err != nil {
+		os.RemoveAll(dir)
+		return internal.Pod{}, err
+	}
+	p.pods[id] = lp
+	return lp.pod, nil
+}
+
+func (p *Pool) GetPod(ctx context.Context, podID string) (internal.Pod, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	lp, ok := p.pods[podID]
+	if !ok {
+		return internal.Pod{}, fmt.Errorf("%w: %s", internal.ErrPodNotFound, podID)
+	}
+	return lp.pod, nil
+}
+
+func (p *Pool) ListPods(ctx context.Context, options internal.ListOptions) ([]internal.Pod, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pods := make([]internal.Pod, 0, len(p.pods))
+	for _, lp := range p.pods {
+		if options.Match(lp.pod) {
+			pods = append(pods, lp.pod)
+		}
+	}
+	return pods, nil
+}
+
+func (p *Pool) DestroyPod(ctx context.Context, podID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	lp, ok := p.pods[podID]
+	if !ok {
+		return fmt.Errorf("%w: %s", internal.ErrPodNotFound, podID)
+	}
+	if lp.pod.Status == internal.StatusRunning {
+		lp.server.close()
+	}
+	delete(p.pods, podID)
+	return os.RemoveAll(lp.dir)
+}
+
+func (p *Pool) StopPod(ctx context.Context, podID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	lp, ok := p.pods[podID]
+	if !ok {
+		return fmt.Errorf("%w: %s", internal.ErrPodNotFound, podID)
+	}
+	if lp.pod.Status != internal.StatusRunning {
+		return fmt.Errorf("failed to stop pod, pod %s is %s", podID, lp.pod.Status)
+	}
+	lp.server.close()
+	lp.pod.Status = internal.StatusStopped
+	return nil
+}
+
+func (p *Pool) StartPod(ctx context.Context, podID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	lp, ok := p.pods[podID]
+	if !ok {
+		return fmt.Errorf("%w: %s", internal.ErrPodNotFound, podID)
+	}
+	if lp.pod.Status != internal.StatusStopped {
+		return fmt.Errorf("failed to start pod, pod %s is %s", podID, lp.pod.Status)
+	}
+	return p.boot(lp)
+}
+
+func (p *Pool) RestartPod(ctx context.Context, podID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	lp, ok := p.pods[podID]
+	if !ok {
+		return fmt.Errorf("%w: %s", internal.ErrPodNotFound, podID)
+	}
+	if lp.pod.Status != internal.StatusRunning {
+		return fmt.Errorf("failed to restart pod, pod %s is %s", podID, lp.pod.Status)
+	}
+	lp.server.close()
+	return p.boot(lp)
+}
+
+// ListOffers returns no offers, local pods have no GPUs
+func (p *Pool) ListOffers(ctx context.Context) ([]internal.Offer, error) {
+	return []internal.Offer{}, nil
+}
+
+// boot starts the SSH server of a pod again, preferring its previous port,
+// the caller must hold p.mu. Pods adopted from disk get a new server with
+// a new password and host key.
+func (p *Pool) boot(lp *localPod) error {
+	if lp.server == nil {
+		srv, password, err := p.newServer(lp.dir)
+		if err != nil {
+			lp.pod.Status = internal.StatusError
+			return err
+		}
+		lp.server = srv
+		lp.pod.Password = password
+		lp.pod.SSHDomain = p.opts.ListenHost
+		lp.pod.SSHUser = sshUser
+	}
+	if err := lp.server.start(net.JoinHostPort(p.opts.ListenHost, lp.pod.SSHPort)); err != nil {
+		if err := lp.server.start(net.JoinHostPort(p.opts.ListenHost, "0")); err != nil {
+			lp.pod.Status = internal.StatusError
+			return fmt.Errorf("failed to start ssh server: %w", err)
+		}
+	}
+	lp.pod.SSHPort = strconv.Itoa(lp.server.port())
+	lp.pod.Status = internal.StatusRunning
+	return nil
+}
+
+// newServer returns an SSH server for the pod directory with a random
+// password and host key
+func (p *Pool) newServer(dir string) (*server, string, error) {
+	password, err := randomPassword()
+	if err != nil {
+		return nil, "", err
+	}
+	_, hostKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate host key: %w", err)
+	}
+	signer, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate host key: %w", err)
+	}
+	return newServer(dir, p.opts.Shell, sshUser, password, p.opts.ListenHost, signer), password, nil
+}
+
+func randomPassword() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate password: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
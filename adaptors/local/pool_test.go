@@ -0,0 +1,195 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/funstory-ai/gobun/internal"
+	"github.com/funstory-ai/gobun/internal/ssh"
+)
+
+func TestAdoptPods(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	// the pod of a gobun that exited, process IDs never reach 1<<30
+	left := "local-1073741824-1"
+	if err := os.MkdirAll(filepath.Join(dir, left, "data"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	first := NewPool(Options{Dir: dir})
+	pod, err := first.CreatePod(ctx, internal.PodOptions{})
+	if err != nil {
+		t.Fatalf("CreatePod: %v", err)
+	}
+	defer first.DestroyPod(ctx, pod.ID)
+
+	// the pods of a running process are not taken over
+	second := NewPool(Options{Dir: dir})
+	if _, err := second.GetPod(ctx, pod.ID); !errors.Is(err, internal.ErrPodNotFound) {
+		t.Errorf("GetPod of a pod of a running process = %v, want %v", err, internal.ErrPodNotFound)
+	}
+
+	adopted, err := second.GetPod(ctx, left)
+	if err != nil {
+		t.Fatalf("GetPod of a left pod: %v", err)
+	}
+	if adopted.Status != internal.StatusStopped || adopted.SSHPort != "" {
+		t.Errorf("left pod is %s on port %q, want %s without SSH", adopted.Status, adopted.SSHPort, internal.StatusStopped)
+	}
+	if err := second.StartPod(ctx, left); err != nil {
+		t.Fatalf("StartPod: %v", err)
+	}
+	if adopted, _ = second.GetPod(ctx, left); adopted.Status != internal.StatusRunning || adopted.SSHPort == "" || adopted.Password == "" {
+		t.Errorf("started pod is %s on port %q", adopted.Status, adopted.SSHPort)
+	}
+	if err := second.DestroyPod(ctx, left); err != nil {
+		t.Fatalf("DestroyPod: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, left)); !os.IsNotExist(err) {
+		t.Errorf("directory of the destroyed pod is left: %v", err)
+	}
+}
+
+// connect opens an SSH client to a running pod
+func connect(t *testing.T, ctx context.Context, pod internal.Pod) ssh.Client {
+	t.Helper()
+	port, err := strconv.Atoi(pod.SSHPort)
+	if err != nil {
+		t.Fatalf("pod has no SSH port: %q", pod.SSHPort)
+	}
+	client, err := ssh.NewClient(ctx, ssh.Options{
+		Server:   pod.SSHDomain,
+		Port:     port,
+		User:     pod.SSHUser,
+		Password: pod.Password,
+		Auth:     true,
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// freeAddress returns a loopback address with a port nothing listens on
+func freeAddress(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+// dial connects to addr, retrying while a forward is still starting
+func dial(t *testing.T, addr string) net.Conn {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			return conn
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("dial %s: %v", addr, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPodOverSSH(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	pool := NewPool(Options{Dir: t.TempDir()})
+	pod, err := pool.CreatePod(ctx, internal.PodOptions{})
+	if err != nil {
+		t.Fatalf("CreatePod: %v", err)
+	}
+	defer pool.DestroyPod(ctx, pod.ID)
+	if pod.SSHDomain != "127.0.0.1" {
+		t.Errorf("pod listens on %s, want 127.0.0.1", pod.SSHDomain)
+	}
+
+	// ExecWithOutput closes its client, the forwards below get their own
+	output, err := connect(t, ctx, pod).ExecWithOutput(ctx, "echo hello; pwd")
+	if err != nil {
+		t.Fatalf("ExecWithOutput: %v: %s", err, output)
+	}
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if len(lines) != 2 || lines[0] != "hello" || filepath.Base(lines[1]) != pod.ID {
+		t.Errorf("ExecWithOutput = %q, want hello and the directory of the pod", output)
+	}
+
+	// A local forward reaches a server next to the pod
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Close()
+	go func() {
+		for {
+			conn, err := target.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+	client := connect(t, ctx, pod)
+	forwardCtx, stopForward := context.WithCancel(ctx)
+	defer stopForward()
+	local := freeAddress(t)
+	go func() { _ = client.LocalForward(forwardCtx, local, target.Addr().String()) }()
+	conn := dial(t, local)
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	reply := make([]byte, 4)
+	if _, err := io.ReadFull(conn, reply); err != nil || string(reply) != "ping" {
+		t.Errorf("forwarded echo = %q, %v, want ping", reply, err)
+	}
+	conn.Close()
+	stopForward()
+
+	// Remote forwards may not open the machine to the network
+	rejectCtx, stopReject := context.WithTimeout(ctx, 5*time.Second)
+	defer stopReject()
+	if err := client.RemoteForward(rejectCtx, "0.0.0.0:0", target.Addr().String()); err == nil || rejectCtx.Err() != nil {
+		t.Errorf("RemoteForward on 0.0.0.0 = %v, want it rejected", err)
+	}
+
+	if err := pool.StopPod(ctx, pod.ID); err != nil {
+		t.Fatalf("StopPod: %v", err)
+	}
+	if conn, err := net.Dial("tcp", net.JoinHostPort(pod.SSHDomain, pod.SSHPort)); err == nil {
+		conn.Close()
+		t.Error("the SSH server of a stopped pod accepts connections")
+	}
+	if err := pool.StartPod(ctx, pod.ID); err != nil {
+		t.Fatalf("StartPod: %v", err)
+	}
+	started, err := pool.GetPod(ctx, pod.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if started.Status != internal.StatusRunning || started.SSHPort != pod.SSHPort {
+		t.Errorf("started pod is %s on port %s, want %s on port %s", started.Status, started.SSHPort, internal.StatusRunning, pod.SSHPort)
+	}
+	if output, err := connect(t, ctx, started).ExecWithOutput(ctx, "echo again"); err != nil || strings.TrimSpace(string(output)) != "again" {
+		t.Errorf("ExecWithOutput after StartPod = %q, %v", output, err)
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !linux
+
+package local
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// openPty fails, pseudo terminals are only allocated on Linux
+func openPty() (master, tty *os.File, err error) {
+	return nil, nil, errors.New("pseudo terminals are not supported on this platform")
+}
+
+func setWinsize(master *os.File, columns, rows uint32) error {
+	return nil
+}
+
+func setControllingTerminal(cmd *exec.Cmd, tty *os.File) {}
@@ -0,0 +1,486 @@
+package local
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+)
+
+// server is a minimal SSH server that runs commands and shells inside a
+// working directory. It supports exec, shell, env, local forwarding
+// (direct-tcpip) and remote forwarding (tcpip-forward). Pseudo terminals
+// are allocated on Linux only, elsewhere pty-req is rejected. Remote
+// forwards listen on loopback or on listenHost only.
+type server struct {
+	dir        string
+	shell      string
+	user       string
+	password   string
+	listenHost string
+	config     *ssh.ServerConfig
+
+	mu       sync.Mutex
+	listener net.Listener
+	// ctx is cancelled on close to kill the running commands
+	ctx    context.Context
+	cancel context.CancelFunc
+	conns  map[*ssh.ServerConn]struct{}
+	wg     sync.WaitGroup
+}
+
+func newServer(dir, shell, user, password, listenHost string, hostKey ssh.Signer) *server {
+	s := &server{
+		dir:        dir,
+		shell:      shell,
+		user:       user,
+		password:   password,
+		listenHost: listenHost,
+		conns:      make(map[*ssh.ServerConn]struct{}),
+	}
+	s.config = &ssh.ServerConfig{
+		PasswordCallback: func(meta ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			userOK := subtle.ConstantTimeCompare([]byte(meta.User()), []byte(s.user)) == 1
+			passwordOK := subtle.ConstantTimeCompare(password, []byte(s.password)) == 1
+			if userOK && passwordOK {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("password rejected for %s", meta.User())
+		},
+	}
+	s.config.AddHostKey(hostKey)
+	return s
+}
+
+// start listens on addr and serves connections in the background
+func (s *server) start(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.listener = listener
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				s.handleConn(conn)
+			}()
+		}
+	}()
+	return nil
+}
+
+// port returns the port the server listens on
+func (s *server) port() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// close stops listening, drops all connections and waits for the handlers
+func (s *server) close() error {
+	s.mu.Lock()
+	err := s.listener.Close()
+	s.cancel()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return err
+}
+
+func (s *server) handleConn(netConn net.Conn) {
+	conn, chans, reqs, err := ssh.NewServerConn(netConn, s.config)
+	if err != nil {
+		logrus.WithError(err).Debug("local ssh handshake failed")
+		netConn.Close()
+		return
+	}
+	s.mu.Lock()
+	s.conns[conn] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	forwards := newForwards(conn, s.listenHost)
+	defer forwards.closeAll()
+	go forwards.handleRequests(reqs)
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for newChannel := range chans {
+		switch newChannel.ChannelType() {
+		case "session":
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.handleSession(newChannel)
+			}()
+		case "direct-tcpip":
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				handleDirectTCPIP(newChannel)
+			}()
+		default:
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unsupported channel type")
+		}
+	}
+}
+
+// defaultPath is the PATH of pod commands when gobun itself has none
+const defaultPath = "/usr/local/bin:/usr/bin:/bin"
+
+// sessionEnv returns the environment pod commands start with. Nothing of
+// the environment of gobun is passed on but PATH, it holds tokens such as
+// XGY_TOKEN that a pod must not see.
+func (s *server) sessionEnv() []string {
+	path := os.Getenv("PATH")
+	if path == "" {
+		path = defaultPath
+	}
+	return []string{"PATH=" + path, "HOME=" + s.dir, "TERM=dumb"}
+}
+
+func (s *server) handleSession(newChannel ssh.NewChannel) {
+	channel, requests, err := newChannel.Accept()
+	if err != nil {
+		return
+	}
+	defer channel.Close()
+
+	env := s.sessionEnv()
+	var master, tty *os.File
+	defer func() {
+		if master != nil {
+			master.Close()
+			tty.Close()
+		}
+	}()
+	for req := range requests {
+		switch req.Type {
+		case "env":
+			var payload struct{ Name, Value string }
+			if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+				_ = req.Reply(false, nil)
+				continue
+			}
+			env = append(env, payload.Name+"="+payload.Value)
+			_ = req.Reply(true, nil)
+		case "pty-req":
+			var payload struct {
+				Term                         string
+				Columns, Rows, Width, Height uint32
+				Modes                        string
+			}
+			if err := ssh.Unmarshal(req.Payload, &payload); err != nil || master != nil {
+				_ = req.Reply(false, nil)
+				continue
+			}
+			if master, tty, err = openPty(); err != nil {
+				logrus.WithError(err).Debug("local ssh pty allocation failed")
+				_ = req.Reply(false, nil)
+				continue
+			}
+			_ = setWinsize(master, payload.Columns, payload.Rows)
+			if payload.Term != "" {
+				env = append(env, "TERM="+payload.Term)
+			}
+			_ = req.Reply(true, nil)
+		case "window-change":
+			var payload struct{ Columns, Rows, Width, Height uint32 }
+			if err := ssh.Unmarshal(req.Payload, &payload); err == nil && master != nil {
+				_ = setWinsize(master, payload.Columns, payload.Rows)
+			}
+			if req.WantReply {
+				_ = req.Reply(true, nil)
+			}
+		case "auth-agent-req@openssh.com":
+			_ = req.Reply(true, nil)
+		case "shell":
+			_ = req.Reply(true, nil)
+			s.run(channel, exec.CommandContext(s.ctx, s.shell, "-i"), env, master, tty)
+			return
+		case "exec":
+			var payload struct{ Command string }
+			if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+				_ = req.Reply(false, nil)
+				continue
+			}
+			_ = req.Reply(true, nil)
+			s.run(channel, exec.CommandContext(s.ctx, s.shell, "-c", payload.Command), env, master, tty)
+			return
+		default:
+			if req.WantReply {
+				_ = req.Reply(false, nil)
+			}
+		}
+	}
+}
+
+// run runs cmd wired to the channel, through the pseudo terminal if master
+// is not nil, and reports its exit status
+func (s *server) run(channel ssh.Channel, cmd *exec.Cmd, env []string, master, tty *os.File) {
+	cmd.Dir = s.dir
+	cmd.Env = env
+	if master != nil {
+		s.runPty(channel, cmd, master, tty)
+		return
+	}
+	cmd.Stdout = channel
+	cmd.Stderr = channel.Stderr()
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		sendExitStatus(channel, 127)
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(channel.Stderr(), "%v\n", err)
+		sendExitStatus(channel, 127)
+		return
+	}
+	go func() {
+		_, _ = io.Copy(stdin, channel)
+		stdin.Close()
+	}()
+	sendExitStatus(channel, exitStatus(cmd.Wait()))
+}
+
+// runPty runs cmd on the pseudo terminal, the output is copied to the
+// channel until the terminal is closed by the last process using it
+func (s *server) runPty(channel ssh.Channel, cmd *exec.Cmd, master, tty *os.File) {
+	setControllingTerminal(cmd, tty)
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(channel.Stderr(), "%v\n", err)
+		sendExitStatus(channel, 127)
+		return
+	}
+	// only the command keeps the terminal open, reading master fails once
+	// it and its children are gone
+	tty.Close()
+	output := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(channel, master)
+		close(output)
+	}()
+	go func() {
+		_, _ = io.Copy(master, channel)
+	}()
+	status := exitStatus(cmd.Wait())
+	select {
+	case <-output:
+	case <-s.ctx.Done():
+	}
+	sendExitStatus(channel, status)
+}
+
+// exitStatus returns the exit status of a command from the error of Wait
+func exitStatus(err error) int {
+	if err == nil {
+		return 0
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exitErr.ExitCode()
+	}
+	return 1
+}
+
+func sendExitStatus(channel ssh.Channel, status int) {
+	payload := make([]byte, 4)
+	binary.BigEndian.PutUint32(payload, uint32(status))
+	_, _ = channel.SendRequest("exit-status", false, payload)
+}
+
+func handleDirectTCPIP(newChannel ssh.NewChannel) {
+	var payload struct {
+		DestAddr string
+		DestPort uint32
+		OrigAddr string
+		OrigPort uint32
+	}
+	if err := ssh.Unmarshal(newChannel.ExtraData(), &payload); err != nil {
+		_ = newChannel.Reject(ssh.ConnectionFailed, "invalid payload")
+		return
+	}
+	target, err := net.Dial("tcp", net.JoinHostPort(payload.DestAddr, strconv.Itoa(int(payload.DestPort))))
+	if err != nil {
+		_ = newChannel.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	channel, requests, err := newChannel.Accept()
+	if err != nil {
+		target.Close()
+		return
+	}
+	go ssh.DiscardRequests(requests)
+	pipe(channel, target)
+}
+
+// forwards tracks the remote forwards (tcpip-forward) of a connection
+type forwards struct {
+	conn *ssh.ServerConn
+	// listenHost is where the SSH server listens, forwards may listen there too
+	listenHost string
+	mu         sync.Mutex
+	listeners  map[string]net.Listener
+}
+
+func newForwards(conn *ssh.ServerConn, listenHost string) *forwards {
+	return &forwards{
+		conn:       conn,
+		listenHost: listenHost,
+		listeners:  make(map[string]net.Listener),
+	}
+}
+
+func (f *forwards) handleRequests(reqs <-chan *ssh.Request) {
+	for req := range reqs {
+		var payload struct {
+			BindAddr string
+			BindPort uint32
+		}
+		switch req.Type {
+		case "tcpip-forward":
+			if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+				_ = req.Reply(false, nil)
+				continue
+			}
+			port, err := f.listen(payload.BindAddr, payload.BindPort)
+			if err != nil {
+				logrus.WithError(err).Debug("local ssh remote forward rejected")
+				_ = req.Reply(false, nil)
+				continue
+			}
+			reply := make([]byte, 4)
+			binary.BigEndian.PutUint32(reply, port)
+			_ = req.Reply(true, reply)
+		case "cancel-tcpip-forward":
+			if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+				_ = req.Reply(false, nil)
+				continue
+			}
+			f.cancel(net.JoinHostPort(payload.BindAddr, strconv.Itoa(int(payload.BindPort))))
+			_ = req.Reply(true, nil)
+		default:
+			if req.WantReply {
+				_ = req.Reply(false, nil)
+			}
+		}
+	}
+}
+
+// bindHost returns the host a remote forward to bindAddr listens on. A
+// client must not open ports of the machine to the network when gobun only
+// serves the pod on loopback, so only loopback addresses and the listen
+// host are allowed, an empty address means loopback as in OpenSSH without
+// GatewayPorts
+func (f *forwards) bindHost(bindAddr string) (string, error) {
+	switch bindAddr {
+	case "", "localhost":
+		return "127.0.0.1", nil
+	case f.listenHost:
+		return bindAddr, nil
+	}
+	if ip := net.ParseIP(bindAddr); ip != nil && ip.IsLoopback() {
+		return bindAddr, nil
+	}
+	return "", fmt.Errorf("remote forwards cannot listen on %s", bindAddr)
+}
+
+func (f *forwards) listen(bindAddr string, bindPort uint32) (uint32, error) {
+	host, err := f.bindHost(bindAddr)
+	if err != nil {
+		return 0, err
+	}
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(int(bindPort))))
+	if err != nil {
+		return 0, err
+	}
+	port := uint32(listener.Addr().(*net.TCPAddr).Port)
+	f.mu.Lock()
+	f.listeners[net.JoinHostPort(bindAddr, strconv.Itoa(int(port)))] = listener
+	f.mu.Unlock()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			origin := conn.RemoteAddr().(*net.TCPAddr)
+			payload := ssh.Marshal(struct {
+				Addr     string
+				Port     uint32
+				OrigAddr string
+				OrigPort uint32
+			}{bindAddr, port, origin.IP.String(), uint32(origin.Port)})
+			channel, requests, err := f.conn.OpenChannel("forwarded-tcpip", payload)
+			if err != nil {
+				conn.Close()
+				continue
+			}
+			go ssh.DiscardRequests(requests)
+			go pipe(channel, conn)
+		}
+	}()
+	return port, nil
+}
+
+func (f *forwards) cancel(addr string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if listener, ok := f.listeners[addr]; ok {
+		listener.Close()
+		delete(f.listeners, addr)
+	}
+}
+
+func (f *forwards) closeAll() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for addr, listener := range f.listeners {
+		listener.Close()
+		delete(f.listeners, addr)
+	}
+}
+
+// pipe copies between the channel and the connection until either side is done
+func pipe(channel ssh.Channel, conn net.Conn) {
+	done := make(chan struct{}, 2)
+	go func() {
+		_, _ = io.Copy(channel, conn)
+		done <- struct{}{}
+	}()
+	go func() {
+		_, _ = io.Copy(conn, channel)
+		done <- struct{}{}
+	}()
+	<-done
+	channel.Close()
+	conn.Close()
+}
@@ -0,0 +1,237 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/funstory-ai/gobun/internal"
+	"gopkg.in/yaml.v3"
+)
+
+// Mapping describes how a REST provider maps onto internal.Pool, it is
+// loaded from a YAML or JSON file. See examples/xiangongyun.yaml.
+type Mapping struct {
+	// Name is the pool ID the provider is registered under
+	Name string `yaml:"name"`
+	// BaseURL is prepended to the path of every endpoint
+	BaseURL string `yaml:"base_url"`
+	Auth    Auth   `yaml:"auth"`
+	// Success tells whether a response succeeded, if unset only the HTTP status is checked
+	Success *Success `yaml:"success"`
+	// Message is the path of the error message in a response
+	Message   string    `yaml:"message"`
+	Endpoints Endpoints `yaml:"endpoints"`
+	// Fields maps the snake_case name of a Pod field to its path in an item
+	Fields map[string]string `yaml:"fields"`
+	// OfferFields maps the snake_case name of an Offer field to its path in an offer item
+	OfferFields map[string]string `yaml:"offer_fields"`
+	// GPUModels maps a GPU model to the provider's name for it
+	GPUModels map[internal.GPUModel]string `yaml:"gpu_models"`
+	// Statuses maps a provider status to a pod status, unknown statuses are kept
+	Statuses map[string]internal.PodStatus `yaml:"statuses"`
+}
+
+// Auth is how requests are authenticated
+type Auth struct {
+	// Header is the request header carrying the credential, e.g. Authorization
+	Header string `yaml:"header"`
+	// Prefix is prepended to the credential, e.g. "Bearer "
+	Prefix string `yaml:"prefix"`
+	// Env is the environment variable holding the credential
+	Env string `yaml:"env"`
+}
+
+// Success compares the value at Path with Value to tell whether a response succeeded
+type Success struct {
+	Path  string      `yaml:"path"`
+	Value interface{} `yaml:"value"`
+}
+
+// Endpoints are the requests behind the Pool methods, stop, start, restart and offers are optional
+type Endpoints struct {
+	List    Endpoint  `yaml:"list"`
+	Get     Endpoint  `yaml:"get"`
+	Create  Endpoint  `yaml:"create"`
+	Destroy Endpoint  `yaml:"destroy"`
+	Stop    *Endpoint `yaml:"stop"`
+	Start   *Endpoint `yaml:"start"`
+	Restart *Endpoint `yaml:"restart"`
+	Offers  *Endpoint `yaml:"offers"`
+}
+
+// Endpoint is a request template, Path and Body are text/template strings
+// executed with the pod ID (.ID) or the create options (.GPUModel, .GPUCount,
+// .Name, .DataDiskSize, .Image, .DataCenter), the json function quotes a
+// value as JSON and path escapes a path segment. Creating a pod with an
+// option that the create templates do not use fails.
+type Endpoint struct {
+	Method string `yaml:"method"`
+	Path   string `yaml:"path"`
+	Body   string `yaml:"body"`
+	// Items is the path of the item list in a list or offers response
+	Items string `yaml:"items"`
+	// Item is the path of the item in a get response
+	Item string `yaml:"item"`
+	// ID is the path of the new pod ID in a create response
+	ID string `yaml:"id"`
+
+	path *template.Template
+	body *template.Template
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"path": url.PathEscape,
+}
+
+// LoadMapping reads and validates a mapping file
+func LoadMapping(file string) (*Mapping, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var m Mapping
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parsing %s failed: %w", file, err)
+	}
+	if err := m.compile(); err != nil {
+		return nil, fmt.Errorf("invalid mapping %s: %w", file, err)
+	}
+	return &m, nil
+}
+
+// compile validates the mapping and parses its templates
+func (m *Mapping) compile() error {
+	if m.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if m.BaseURL == "" {
+		return fmt.Errorf("base_url is required")
+	}
+	if m.Fields["id"] == "" {
+		return fmt.Errorf("fields.id is required")
+	}
+	for field := range m.Fields {
+		if _, ok := podFields[field]; !ok {
+			return fmt.Errorf("unknown pod field %q", field)
+		}
+	}
+	for field := range m.OfferFields {
+		if _, ok := offerFields[field]; !ok {
+			return fmt.Errorf("unknown offer field %q", field)
+		}
+	}
+	endpoints := map[string]*Endpoint{
+		"list":    &m.Endpoints.List,
+		"get":     &m.Endpoints.Get,
+		"create":  &m.Endpoints.Create,
+		"destroy": &m.Endpoints.Destroy,
+		"stop":    m.Endpoints.Stop,
+		"start":   m.Endpoints.Start,
+		"restart": m.Endpoints.Restart,
+		"offers":  m.Endpoints.Offers,
+	}
+	for name, endpoint := range endpoints {
+		if endpoint == nil {
+			continue
+		}
+		if endpoint.Path == "" {
+			return fmt.Errorf("endpoints.%s.path is required", name)
+		}
+		if endpoint.Method == "" {
+			endpoint.Method = "GET"
+		}
+		endpoint.Method = strings.ToUpper(endpoint.Method)
+		var err error
+		if endpoint.path, err = template.New(name + ".path").Funcs(templateFuncs).Parse(endpoint.Path); err != nil {
+			return err
+		}
+		if endpoint.Body != "" {
+			if endpoint.body, err = template.New(name + ".body").Funcs(templateFuncs).Parse(endpoint.Body); err != nil {
+				return err
+			}
+		}
+	}
+	if m.Endpoints.Create.ID == "" {
+		return fmt.Errorf("endpoints.create.id is required")
+	}
+	return nil
+}
+
+// uses reports whether the templates of the endpoint refer to a field of
+// their data, as .Field or $.Field
+func (e *Endpoint) uses(field string) bool {
+	for _, tmpl := range []*template.Template{e.path, e.body} {
+		if tmpl != nil && tmpl.Tree != nil && nodeUses(tmpl.Tree.Root, field) {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeUses walks a parsed template looking for a field of the data
+func nodeUses(node parse.Node, field string) bool {
+	switch node := node.(type) {
+	case *parse.ListNode:
+		if node == nil {
+			return false
+		}
+		for _, n := range node.Nodes {
+			if nodeUses(n, field) {
+				return true
+			}
+		}
+	case *parse.ActionNode:
+		return nodeUses(node.Pipe, field)
+	case *parse.PipeNode:
+		if node == nil {
+			return false
+		}
+		for _, cmd := range node.Cmds {
+			if nodeUses(cmd, field) {
+				return true
+			}
+		}
+	case *parse.CommandNode:
+		for _, arg := range node.Args {
+			if nodeUses(arg, field) {
+				return true
+			}
+		}
+	case *parse.FieldNode:
+		return node.Ident[0] == field
+	case *parse.VariableNode:
+		return len(node.Ident) > 1 && node.Ident[0] == "$" && node.Ident[1] == field
+	case *parse.ChainNode:
+		return nodeUses(node.Node, field)
+	case *parse.IfNode:
+		return nodeUses(node.Pipe, field) || nodeUses(node.List, field) || nodeUses(node.ElseList, field)
+	case *parse.RangeNode:
+		return nodeUses(node.Pipe, field) || nodeUses(node.List, field) || nodeUses(node.ElseList, field)
+	case *parse.WithNode:
+		return nodeUses(node.Pipe, field) || nodeUses(node.List, field) || nodeUses(node.ElseList, field)
+	case *parse.TemplateNode:
+		return nodeUses(node.Pipe, field)
+	}
+	return false
+}
+
+// render executes a template of the endpoint
+func render(tmpl *template.Template, data interface{}) (string, error) {
+	if tmpl == nil {
+		return "", nil
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,472 @@
+// Package rest implements a generic pool for GPU clouds with simple REST
+// APIs, everything provider specific comes from a Mapping file, so that
+// onboarding a new provider is a config change instead of new Go code.
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/funstory-ai/gobun/internal"
+	"github.com/funstory-ai/gobun/internal/utils/jsonpath"
+)
+
+const (
+	// DefaultTimeout bounds a single request when the caller's context has no deadline
+	DefaultTimeout = 60 * time.Second
+)
+
+// RegisterDir registers a pool for every mapping file (*.yaml, *.yml or
+// *.json) in dir, a missing dir registers nothing
+func RegisterDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	registered := make(map[string]bool)
+	for _, name := range internal.RegisteredPools() {
+		registered[name] = true
+	}
+	for _, entry := range entries {
+		switch filepath.Ext(entry.Name()) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+		mapping, err := LoadMapping(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		if registered[mapping.Name] {
+			return fmt.Errorf("pool %s in %s is already registered", mapping.Name, entry.Name())
+		}
+		registered[mapping.Name] = true
+		internal.RegisterPool(mapping.Name, func() (internal.Pool, error) {
+			credential := ""
+			if mapping.Auth.Env != "" {
+				credential = os.Getenv(mapping.Auth.Env)
+				if credential == "" {
+					return nil, fmt.Errorf("environment variable %s is not set", mapping.Auth.Env)
+				}
+			}
+			return NewPool(mapping, credential), nil
+		})
+	}
+	return nil
+}
+
+type Pool struct {
+	mapping    *Mapping
+	credential string
+	client     *http.Client
+	gpuNames   *internal.GPUNames
+}
+
+// NewPool creates a pool from a loaded mapping, credential is sent in the auth header
+func NewPool(mapping *Mapping, credential string) *Pool {
+	return &Pool{
+		mapping:    mapping,
+		credential: credential,
+		client:     &http.Client{},
+		gpuNames:   internal.NewGPUNames(mapping.GPUModels),
+	}
+}
+
+func (p *Pool) ID() string {
+	return p.mapping.Name
+}
+
+func (p *Pool) ListPods(ctx context.Context, options internal.ListOptions) ([]internal.Pod, error) {
+	items, err := p.list(ctx, &p.mapping.Endpoints.List)
+	if err != nil {
+		return nil, err
+	}
+	pods := make([]internal.Pod, 0, len(items))
+	for _, item := range items {
+		pod, err := p.toPod(item)
+		if err != nil {
+			return nil, err
+		}
+		pods = append(pods, pod)
+	}
+	return internal.FilterPods(pods, options), nil
+}
+
+func (p *Pool) GetPod(ctx context.Context, podID string) (internal.Pod, error) {
+	endpoint := &p.mapping.Endpoints.Get
+	response, err := p.do(ctx, endpoint, podData{ID: podID})
+	if err != nil {
+		return internal.Pod{}, err
+	}
+	item, ok, err := jsonpath.Get(response, endpoint.Item)
+	if err != nil {
+		return internal.Pod{}, err
+	}
+	if !ok {
+		return internal.Pod{}, fmt.Errorf("%w: %s", internal.ErrPodNotFound, podID)
+	}
+	return p.toPod(item)
+}
+
+// CheckPodOptions rejects the options that the create endpoint of the
+// mapping does not use
+func (p *Pool) CheckPodOptions(options internal.PodOptions) error {
+	endpoint := &p.mapping.Endpoints.Create
+	for _, option := range []struct {
+		set   bool
+		name  string
+		field string
+	}{
+		{options.Name != "", "a pod name", "Name"},
+		{options.DataDiskSize > 0, "a data disk size", "DataDiskSize"},
+		{options.Image != "", "an image", "Image"},
+		{options.DataCenter != "", "a data center", "DataCenter"},
+	} {
+		if option.set && !endpoint.uses(option.field) {
+			return fmt.Errorf("pool %s cannot create pods with %s, endpoints.create of its mapping does not use .%s", p.ID(), option.name, option.field)
+		}
+	}
+	return nil
+}
+
+func (p *Pool) CreatePod(ctx context.Context, options internal.PodOptions) (internal.Pod, error) {
+	if err := internal.CheckPodOptions(p, options); err != nil {
+		return internal.Pod{}, err
+	}
+	gpuModel, err := p.gpuNames.Name(options.GPUModel)
+	if err != nil {
+		return internal.Pod{}, err
+	}
+	endpoint := &p.mapping.Endpoints.Create
+	response, err := p.do(ctx, endpoint, createData{
+		GPUModel:     gpuModel,
+		GPUCount:     options.GPUCount,
+		Name:         options.Name,
+		DataDiskSize: options.DataDiskSize,
+		Image:        options.Image,
+		DataCenter:   options.DataCenter,
+	})
+	if err != nil {
+		return internal.Pod{}, fmt.Errorf("failed to create pod: %w", err)
+	}
+	id, ok, err := jsonpath.Get(response, endpoint.ID)
+	if err != nil {
+		return internal.Pod{}, err
+	}
+	if !ok {
+		return internal.Pod{}, fmt.Errorf("failed to create pod: no %s in the response", endpoint.ID)
+	}
+	return internal.GetCreatedPod(ctx, p, toString(id))
+}
+
+func (p *Pool) DestroyPod(ctx context.Context, podID string) error {
+	return p.action(ctx, "destroy", &p.mapping.Endpoints.Destroy, podID)
+}
+
+func (p *Pool) StopPod(ctx context.Context, podID string) error {
+	return p.action(ctx, "stop", p.mapping.Endpoints.Stop, podID)
+}
+
+func (p *Pool) StartPod(ctx context.Context, podID string) error {
+	return p.action(ctx, "start", p.mapping.Endpoints.Start, podID)
+}
+
+func (p *Pool) RestartPod(ctx context.Context, podID string) error {
+	return p.action(ctx, "restart", p.mapping.Endpoints.Restart, podID)
+}
+
+func (p *Pool) ListOffers(ctx context.Context) ([]internal.Offer, error) {
+	endpoint := p.mapping.Endpoints.Offers
+	if endpoint == nil {
+		return nil, fmt.Errorf("pool %s does not support listing offers", p.ID())
+	}
+	items, err := p.list(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	offers := make([]internal.Offer, 0, len(items))
+	for _, item := range items {
+		offer := internal.Offer{PoolID: p.ID()}
+		for field, path := range p.mapping.OfferFields {
+			value, ok, err := jsonpath.Get(item, path)
+			if err != nil {
+				return nil, err
+			}
+			if ok && value != nil {
+				offerFields[field](&offer, value)
+			}
+		}
+		offer.GPUModel = p.gpuModel(string(offer.GPUModel))
+		offers = append(offers, offer)
+	}
+	return offers, nil
+}
+
+// podData is the template data of the endpoints acting on a pod
+type podData struct {
+	ID string
+}
+
+// createData is the template data of the create endpoint
+type createData struct {
+	GPUModel     string
+	GPUCount     int
+	Name         string
+	DataDiskSize int64
+	Image        string
+	DataCenter   string
+}
+
+func (p *Pool) action(ctx context.Context, action string, endpoint *Endpoint, podID string) error {
+	if endpoint == nil {
+		return fmt.Errorf("pool %s does not support %s", p.ID(), action)
+	}
+	if _, err := p.do(ctx, endpoint, podData{ID: podID}); err != nil {
+		return fmt.Errorf("failed to %s pod: %w", action, err)
+	}
+	return nil
+}
+
+// list sends the request of an endpoint and returns the items of the response
+func (p *Pool) list(ctx context.Context, endpoint *Endpoint) ([]interface{}, error) {
+	response, err := p.do(ctx, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	items, ok, err := jsonpath.Get(response, endpoint.Items)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || items == nil {
+		return nil, nil
+	}
+	list, ok := items.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s is not a list in the response", endpoint.Items)
+	}
+	return list, nil
+}
+
+// do sends the request of an endpoint and returns the decoded response
+func (p *Pool) do(ctx context.Context, endpoint *Endpoint, data interface{}) (interface{}, error) {
+	path, err := render(endpoint.path, data)
+	if err != nil {
+		return nil, err
+	}
+	body, err := render(endpoint.body, data)
+	if err != nil {
+		return nil, err
+	}
+	var reader io.Reader
+	if body != "" {
+		reader = bytes.NewBufferString(body)
+	}
+	reqCtx := ctx
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		reqCtx, cancel = context.WithTimeout(ctx, DefaultTimeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(reqCtx, endpoint.Method, p.mapping.BaseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.mapping.Auth.Header != "" {
+		req.Header.Set(p.mapping.Auth.Header, p.mapping.Auth.Prefix+p.credential)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var response interface{}
+	decoder := json.NewDecoder(resp.Body)
+	// Numbers are kept as written, IDs beyond 2^53 do not survive a float64
+	decoder.UseNumber()
+	decodeErr := decoder.Decode(&response)
+	if decodeErr == io.EOF {
+		decodeErr = nil
+	}
+	message := ""
+	if p.mapping.Message != "" && decodeErr == nil {
+		if value, ok, _ := jsonpath.Get(response, p.mapping.Message); ok {
+			message = toString(value)
+		}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// Error pages of gateways are often not JSON, the status alone tells
+		// what went wrong
+		if kind := statusError(resp.StatusCode); kind != nil {
+			return nil, fmt.Errorf("%s %s: %w: http status %d %s", endpoint.Method, path, kind, resp.StatusCode, message)
+		}
+		return nil, fmt.Errorf("%s %s: http status %d %s", endpoint.Method, path, resp.StatusCode, message)
+	}
+	if decodeErr != nil {
+		return nil, fmt.Errorf("%s %s: decoding response with http status %d failed: %w", endpoint.Method, path, resp.StatusCode, decodeErr)
+	}
+	if success := p.mapping.Success; success != nil {
+		value, _, err := jsonpath.Get(response, success.Path)
+		if err != nil {
+			return nil, err
+		}
+		if toString(value) != toString(success.Value) {
+			return nil, fmt.Errorf("%s %s: response %s: %v %s", endpoint.Method, path, success.Path, value, message)
+		}
+	}
+	return response, nil
+}
+
+// statusError maps an HTTP status onto the errors of package internal
+func statusError(status int) error {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return internal.ErrUnauthorized
+	case status == http.StatusNotFound:
+		return internal.ErrPodNotFound
+	case status == http.StatusTooManyRequests:
+		return internal.ErrRateLimited
+	case status >= 500:
+		return internal.ErrTransient
+	default:
+		return nil
+	}
+}
+
+// toPod maps an item of a response onto a pod with the configured fields
+func (p *Pool) toPod(item interface{}) (internal.Pod, error) {
+	pod := internal.Pod{
+		PoolID: p.ID(),
+		Pool:   p,
+	}
+	for field, path := range p.mapping.Fields {
+		value, ok, err := jsonpath.Get(item, path)
+		if err != nil {
+			return internal.Pod{}, err
+		}
+		if !ok || value == nil {
+			continue
+		}
+		podFields[field](&pod, value)
+	}
+	if status, ok := p.mapping.Statuses[string(pod.Status)]; ok {
+		pod.Status = status
+	}
+	pod.GPUModel = p.gpuModel(string(pod.GPUModel))
+	return pod, nil
+}
+
+// gpuModel returns the GPU model of a provider GPU name, unknown names are kept
+func (p *Pool) gpuModel(name string) internal.GPUModel {
+	return p.gpuNames.Model(name)
+}
+
+// podFields sets a Pod field, by its snake_case name, from a decoded JSON value
+var podFields = map[string]func(*internal.Pod, interface{}){
+	"id":                        func(p *internal.Pod, v interface{}) { p.ID = toString(v) },
+	"create_timestamp":          func(p *internal.Pod, v interface{}) { p.CreateTimestamp = toInt64(v) },
+	"data_center_name":          func(p *internal.Pod, v interface{}) { p.DataCenterName = toString(v) },
+	"name":                      func(p *internal.Pod, v interface{}) { p.Name = toString(v) },
+	"gpu_model":                 func(p *internal.Pod, v interface{}) { p.GPUModel = internal.GPUModel(toString(v)) },
+	"gpu_count":                 func(p *internal.Pod, v interface{}) { p.GPUCount = int(toInt64(v)) },
+	"cpu_model":                 func(p *internal.Pod, v interface{}) { p.CPUModel = toString(v) },
+	"cpu_core_count":            func(p *internal.Pod, v interface{}) { p.CPUCoreCount = int(toInt64(v)) },
+	"memory_size":               func(p *internal.Pod, v interface{}) { p.MemorySize = toInt64(v) },
+	"system_disk_size":          func(p *internal.Pod, v interface{}) { p.SystemDiskSize = toInt64(v) },
+	"data_disk_size":            func(p *internal.Pod, v interface{}) { p.DataDiskSize = toInt64(v) },
+	"expandable_data_disk_size": func(p *internal.Pod, v interface{}) { p.ExpandableDataDiskSize = toInt64(v) },
+	"data_disk_mount_path":      func(p *internal.Pod, v interface{}) { p.DataDiskMountPath = toString(v) },
+	"price_per_hour":            func(p *internal.Pod, v interface{}) { p.PricePerHour = toFloat64(v) },
+	"ssh_domain":                func(p *internal.Pod, v interface{}) { p.SSHDomain = toString(v) },
+	"ssh_key":                   func(p *internal.Pod, v interface{}) { p.SSHKey = toString(v) },
+	"ssh_port":                  func(p *internal.Pod, v interface{}) { p.SSHPort = toString(v) },
+	"ssh_user":                  func(p *internal.Pod, v interface{}) { p.SSHUser = toString(v) },
+	"password":                  func(p *internal.Pod, v interface{}) { p.Password = toString(v) },
+	"status":                    func(p *internal.Pod, v interface{}) { p.Status = internal.PodStatus(toString(v)) },
+	"image_id":                  func(p *internal.Pod, v interface{}) { p.ImageID = toString(v) },
+	"image_type":                func(p *internal.Pod, v interface{}) { p.ImageType = toString(v) },
+	"image_save":                func(p *internal.Pod, v interface{}) { p.ImageSave = toBool(v) },
+}
+
+// offerFields sets an Offer field, by its snake_case name, from a decoded JSON value
+var offerFields = map[string]func(*internal.Offer, interface{}){
+	"gpu_model":        func(o *internal.Offer, v interface{}) { o.GPUModel = internal.GPUModel(toString(v)) },
+	"data_center_id":   func(o *internal.Offer, v interface{}) { o.DataCenterID = toString(v) },
+	"data_center_name": func(o *internal.Offer, v interface{}) { o.DataCenterName = toString(v) },
+	"stock":            func(o *internal.Offer, v interface{}) { o.Stock = int(toInt64(v)) },
+	"price_per_hour":   func(o *internal.Offer, v interface{}) { o.PricePerHour = toFloat64(v) },
+	"cpu_core_count":   func(o *internal.Offer, v interface{}) { o.CPUCoreCount = int(toInt64(v)) },
+	"memory_size":      func(o *internal.Offer, v interface{}) { o.MemorySize = toInt64(v) },
+	"data_disk_size":   func(o *internal.Offer, v interface{}) { o.DataDiskSize = toInt64(v) },
+}
+
+func toString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func toInt64(v interface{}) int64 {
+	if n, ok := v.(json.Number); ok {
+		if i, err := n.Int64(); err == nil {
+			return i
+		}
+	}
+	return int64(toFloat64(v))
+}
+
+func toFloat64(v interface{}) float64 {
+	switch v := v.(type) {
+	case float64:
+		return v
+	case json.Number:
+		f, _ := v.Float64()
+		return f
+	case int:
+		return float64(v)
+	case string:
+		f, _ := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	default:
+		return 0
+	}
+}
+
+func toBool(v interface{}) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case string:
+		b, _ := strconv.ParseBool(v)
+		return b
+	default:
+		return toFloat64(v) != 0
+	}
+}
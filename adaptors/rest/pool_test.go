@@ -0,0 +1,228 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"text/template"
+
+	"github.com/funstory-ai/gobun/internal"
+)
+
+// fakeXianGongYun serves the part of the XianGongYun open API that
+// examples/xiangongyun.yaml maps
+type fakeXianGongYun struct {
+	mu        sync.Mutex
+	instances map[string]map[string]interface{}
+	requests  []string
+}
+
+func (f *fakeXianGongYun) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+	if r.Header.Get("Authorization") != "Bearer token" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var body struct {
+		ID       string `json:"id"`
+		GPUModel string `json:"gpu_model"`
+		GPUCount int    `json:"gpu_count"`
+	}
+	if r.Body != nil {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+	}
+	reply := func(code int, msg string, data interface{}) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": code, "msg": msg, "data": data})
+	}
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/open/instances":
+		list := []interface{}{}
+		for _, instance := range f.instances {
+			list = append(list, instance)
+		}
+		reply(200, "ok", map[string]interface{}{"list": list})
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/open/instance/"):
+		instance, ok := f.instances[strings.TrimPrefix(r.URL.Path, "/open/instance/")]
+		if !ok {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		reply(200, "ok", instance)
+	case r.URL.Path == "/open/instance/deploy":
+		if body.GPUModel == "NVIDIA GeForce RTX 4090 D" {
+			reply(500, "sold out", nil)
+			return
+		}
+		// Beyond 2^53, the ID is only kept when numbers are not decoded as float64
+		id := json.Number("9007199254740993")
+		f.instances[id.String()] = map[string]interface{}{
+			"id":        id,
+			"name":      "pod",
+			"gpu_model": body.GPUModel,
+			"gpu_used":  body.GPUCount,
+			"status":    "deploying",
+		}
+		reply(200, "ok", map[string]interface{}{"id": id})
+	case r.URL.Path == "/open/instance/shutdown_destroy":
+		delete(f.instances, body.ID)
+		reply(200, "ok", nil)
+	case r.URL.Path == "/open/gpus":
+		reply(200, "ok", map[string]interface{}{"list": []interface{}{
+			map[string]interface{}{"gpu_model": "NVIDIA GeForce RTX 4090", "stock": 3, "price_per_hour": 1.98, "data_center_id": 1},
+		}})
+	case r.URL.Path == "/open/overloaded":
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+	default:
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	}
+}
+
+func newTestPool(t *testing.T, credential string) (*Pool, *fakeXianGongYun) {
+	mapping, err := LoadMapping("examples/xiangongyun.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := &fakeXianGongYun{instances: map[string]map[string]interface{}{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	mapping.BaseURL = server.URL
+	return NewPool(mapping, credential), fake
+}
+
+func TestPool(t *testing.T) {
+	ctx := context.Background()
+	pool, fake := newTestPool(t, "token")
+
+	pod, err := pool.CreatePod(ctx, internal.PodOptions{GPUModel: internal.GPUModel("RTX4090"), GPUCount: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pod.ID != "9007199254740993" || pod.GPUModel != internal.GPUModel("RTX4090") || pod.GPUCount != 2 || pod.PoolID != "xiangongyun-rest" {
+		t.Errorf("CreatePod = %+v", pod)
+	}
+
+	pods, err := pool.ListPods(ctx, internal.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pods) != 1 || pods[0].ID != pod.ID || pods[0].Name != "pod" {
+		t.Errorf("ListPods = %+v, want the created pod", pods)
+	}
+
+	got, err := pool.GetPod(ctx, pod.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != pod.ID || got.Status != "deploying" {
+		t.Errorf("GetPod = %+v", got)
+	}
+
+	offers, err := pool.ListOffers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(offers) != 1 || offers[0].GPUModel != internal.GPUModel("RTX4090") || offers[0].Stock != 3 || offers[0].PricePerHour != 1.98 || offers[0].DataCenterID != "1" {
+		t.Errorf("ListOffers = %+v", offers)
+	}
+
+	if err := pool.DestroyPod(ctx, pod.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pool.GetPod(ctx, pod.ID); !errors.Is(err, internal.ErrPodNotFound) {
+		t.Errorf("GetPod after DestroyPod = %v, want %v", err, internal.ErrPodNotFound)
+	}
+
+	want := []string{
+		"POST /open/instance/deploy",
+		"GET /open/instance/9007199254740993",
+		"GET /open/instances",
+		"GET /open/instance/9007199254740993",
+		"GET /open/gpus",
+		"POST /open/instance/shutdown_destroy",
+		"GET /open/instance/9007199254740993",
+	}
+	if strings.Join(fake.requests, "\n") != strings.Join(want, "\n") {
+		t.Errorf("requests:\n%s\nwant:\n%s", strings.Join(fake.requests, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestPoolErrors(t *testing.T) {
+	ctx := context.Background()
+	pool, _ := newTestPool(t, "token")
+
+	// The response succeeds over HTTP, but its code is not the success value
+	_, err := pool.CreatePod(ctx, internal.PodOptions{GPUModel: internal.GPUModel("RTX4090D"), GPUCount: 1})
+	if err == nil || !strings.Contains(err.Error(), "sold out") {
+		t.Errorf("CreatePod = %v, want the message of the response", err)
+	}
+
+	unauthorized, _ := newTestPool(t, "wrong")
+	if _, err := unauthorized.ListPods(ctx, internal.ListOptions{}); !errors.Is(err, internal.ErrUnauthorized) {
+		t.Errorf("ListPods with a wrong token = %v, want %v", err, internal.ErrUnauthorized)
+	}
+
+	tests := []struct {
+		path string
+		want error
+	}{
+		{"/open/overloaded", internal.ErrRateLimited},
+		{"/open/unknown", internal.ErrTransient},
+	}
+	for _, tt := range tests {
+		endpoint := &Endpoint{Method: http.MethodGet, path: template.Must(template.New("path").Parse(tt.path))}
+		if _, err := pool.do(ctx, endpoint, nil); !errors.Is(err, tt.want) {
+			t.Errorf("do(%s) = %v, want %v", tt.path, err, tt.want)
+		}
+	}
+}
+
+func TestCheckPodOptions(t *testing.T) {
+	pool, _ := newTestPool(t, "token")
+	tests := []struct {
+		name    string
+		options internal.PodOptions
+		wantErr bool
+	}{
+		{"gpu only", internal.PodOptions{GPUModel: internal.GPUModel("RTX4090"), GPUCount: 1}, false},
+		{"name", internal.PodOptions{GPUCount: 1, Name: "pod"}, true},
+		{"data disk", internal.PodOptions{GPUCount: 1, DataDiskSize: 100 << 30}, true},
+		{"image", internal.PodOptions{GPUCount: 1, Image: "ubuntu"}, true},
+		{"data center", internal.PodOptions{GPUCount: 1, DataCenter: "1"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := pool.CheckPodOptions(tt.options)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckPodOptions = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEndpointUses(t *testing.T) {
+	tests := []struct {
+		body string
+		want bool
+	}{
+		{`{"name": {{json .Name}}}`, true},
+		{`{"name": {{json $.Name}}}`, true},
+		{`{{if .Name}}{"name": {{json .Name}}}{{end}}`, true},
+		{`{{with .Name}}{"name": {{json .}}}{{end}}`, true},
+		{`{"name": {{json .NameSuffix}}}`, false},
+		{`{"name": "{{.Image}}.Name"}`, false},
+		{`{}`, false},
+	}
+	for _, tt := range tests {
+		endpoint := &Endpoint{body: template.Must(template.New("body").Funcs(templateFuncs).Parse(tt.body))}
+		if got := endpoint.uses("Name"); got != tt.want {
+			t.Errorf("uses(Name) in %s = %v, want %v", tt.body, got, tt.want)
+		}
+	}
+}
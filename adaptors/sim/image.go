@@ -0,0 +1,83 @@
+package sim
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/funstory-ai/gobun/internal"
+)
+
+// defaultImageID is the image of pods created without one
+const defaultImageID = "sim-img-pytorch"
+
+// publicImages are available in every simulated pool
+var publicImages = []internal.Image{
+	{ID: defaultImageID, Name: "PyTorch 2.4 CUDA 12.1", Type: internal.ImageTypePublic, Size: 20 << 30, Status: "available"},
+	{ID: "sim-img-ubuntu", Name: "Ubuntu 22.04 CUDA 12.1", Type: internal.ImageTypePublic, Size: 8 << 30, Status: "available"},
+}
+
+func (p *Pool) ListImages(ctx context.Context) ([]internal.Image, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.listImages(), nil
+}
+
+// SaveImage saves a stopped or running pod as a private image
+func (p *Pool) SaveImage(ctx context.Context, podID string, name string) (internal.Image, error) {
+	if err := ctx.Err(); err != nil {
+		return internal.Image{}, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	sp, err := p.lookup(podID)
+	if err != nil {
+		return internal.Image{}, err
+	}
+	if sp.pod.Status != internal.StatusRunning && sp.pod.Status != internal.StatusStopped {
+		return internal.Image{}, fmt.Errorf("failed to save pod %s as image, pod is %s", podID, sp.pod.Status)
+	}
+	p.nextImageID++
+	image := internal.Image{
+		ID:              fmt.Sprintf("sim-img-%04d", p.nextImageID),
+		PoolID:          p.id,
+		Name:            name,
+		Type:            internal.ImageTypePrivate,
+		Size:            sp.pod.SystemDiskSize,
+		Status:          "available",
+		CreateTimestamp: p.opts.Now().Unix(),
+	}
+	p.images = append(p.images, image)
+	return image, nil
+}
+
+// DestroyImage deletes a private image, public images cannot be deleted
+func (p *Pool) DestroyImage(ctx context.Context, imageID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, image := range p.images {
+		if image.ID == imageID {
+			p.images = append(p.images[:i], p.images[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("image %s not found", imageID)
+}
+
+// listImages returns the public and the saved images, the caller must hold p.mu
+func (p *Pool) listImages() []internal.Image {
+	images := make([]internal.Image, 0, len(publicImages)+len(p.images))
+	for _, image := range publicImages {
+		image.PoolID = p.id
+		images = append(images, image)
+	}
+	return append(images, p.images...)
+}
@@ -0,0 +1,576 @@
+// Package sim implements an in-process pool that simulates a GPU cloud,
+// it spends no money and needs no network, which makes it useful for dry
+// runs of the CLI and for tests. The simulated pods live in memory only, so
+// every gobun invocation starts with an empty pool: commands that create a
+// pod and use it in one run, like up, work, but a pod from create is gone
+// for a later list.
+package sim
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/funstory-ai/gobun/internal"
+)
+
+const (
+	PoolID = "sim"
+
+	// EnvDelay sets how long provisioning takes, e.g. "3s"
+	EnvDelay = "GOBUN_SIM_DELAY"
+	// EnvCreateFailureRate sets the probability in [0, 1] that CreatePod fails
+	EnvCreateFailureRate = "GOBUN_SIM_CREATE_FAILURE_RATE"
+	// EnvProvisionFailureRate sets the probability in [0, 1] that a pod ends up in error
+	EnvProvisionFailureRate = "GOBUN_SIM_PROVISION_FAILURE_RATE"
+	// EnvSeed seeds the random failures so that runs are reproducible
+	EnvSeed = "GOBUN_SIM_SEED"
+)
+
+// dataCenter is where all simulated pods live
+var dataCenter = internal.DataCenter{ID: "1", Name: "simulated", Region: "local"}
+
+func init() {
+	internal.RegisterPool(PoolID, newPoolFromEnv)
+}
+
+// newPoolFromEnv creates the pool with the options from the environment,
+// the pool is empty and its pods end with the process
+func newPoolFromEnv() (internal.Pool, error) {
+	opts := DefaultOptions()
+	if v := os.Getenv(EnvDelay); v != "" {
+		delay, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", EnvDelay, err)
+		}
+		opts.ProvisionDelay = delay
+	}
+	for env, rate := range map[string]*float64{
+		EnvCreateFailureRate:    &opts.CreateFailureRate,
+		EnvProvisionFailureRate: &opts.ProvisionFailureRate,
+	} {
+		if v := os.Getenv(env); v != "" {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil || f < 0 || f > 1 {
+				return nil, fmt.Errorf("invalid %s: %q is not a probability", env, v)
+			}
+			*rate = f
+		}
+	}
+	if v := os.Getenv(EnvSeed); v != "" {
+		seed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", EnvSeed, err)
+		}
+		opts.Seed = seed
+	}
+	return NewPool(opts), nil
+}
+
+// Options configures the simulation
+type Options struct {
+	// ProvisionDelay is how long a pod stays creating after it is created, started or restarted
+	ProvisionDelay time.Duration
+	// CreateFailureRate is the probability that a CreatePod call fails
+	CreateFailureRate float64
+	// ProvisionFailureRate is the probability that provisioning ends in StatusError
+	ProvisionFailureRate float64
+	// Prices is the hourly price of one GPU per model, models without a price cannot be created
+	Prices map[internal.GPUModel]float64
+	// Stock is the number of GPUs of each model, pods that are not stopped hold theirs
+	Stock int
+	// Balance is the prepaid balance the spend is taken from
+	Balance float64
+	// Seed seeds the random failures, 0 picks a random seed
+	Seed int64
+	// Now returns the current time, tests can replace it to drive the state machine
+	Now func() time.Time
+}
+
+// DefaultOptions returns a failure-free simulation with a short provisioning delay
+func DefaultOptions() Options {
+	return Options{
+		ProvisionDelay: 3 * time.Second,
+		Stock:          8,
+		Balance:        100,
+		Prices: map[internal.GPUModel]float64{
+			internal.GPUModelRTX4090:   1.98,
+			internal.GPUModelRTX4090_D: 1.88,
+			internal.GPUModelRTX3090:   1.28,
+			internal.GPUModelA100_40G:  4.98,
+			internal.GPUModelA100_80G:  6.68,
+			internal.GPUModelA800_40G:  4.88,
+			internal.GPUModelA800_80G:  6.48,
+		},
+		Now: time.Now,
+	}
+}
+
+// simPod is the simulated state of a pod
+type simPod struct {
+	pod internal.Pod
+	// readyAt is when a creating pod finishes provisioning
+	readyAt time.Time
+	// fails tells whether the current provisioning ends in error
+	fails bool
+	// runningSince is when the pod last became billable, zero when it is not running
+	runningSince time.Time
+}
+
+type Pool struct {
+	id   string
+	opts Options
+
+	mu     sync.Mutex
+	rand   *rand.Rand
+	nextID int
+	pods   map[string]*simPod
+	// images are the saved private images
+	images      []internal.Image
+	nextImageID int
+	// spent is the cost of the running time that is already settled
+	spent float64
+	// charges are the settled runs, oldest first
+	charges []internal.Charge
+}
+
+func NewPool(opts Options) *Pool {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	seed := opts.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &Pool{
+		id:   PoolID,
+		opts: opts,
+		rand: rand.New(rand.NewSource(seed)),
+		pods: make(map[string]*simPod),
+	}
+}
+
+func (p *Pool) ID() string {
+	return p.id
+}
+
+func (p *Pool) CreatePod(ctx context.Context, options internal.PodOptions) (internal.Pod, error) {
+	if err := ctx.Err(); err != nil {
+		return internal.Pod{}, err
+	}
+	price, ok := p.opts.Prices[options.GPUModel]
+	if !ok {
+		return internal.Pod{}, fmt.Errorf("unsupported gpu model: %s", options.GPUModel)
+	}
+	if options.GPUCount < 1 {
+		return internal.Pod{}, fmt.Errorf("invalid gpu count: %d", options.GPUCount)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.rand.Float64() < p.opts.CreateFailureRate {
+		return internal.Pod{}, fmt.Errorf("failed to create pod: %w: simulated failure", internal.ErrTransient)
+	}
+	if _, err := internal.ChooseOffer(p.offers(), options); err != nil {
+		return internal.Pod{}, fmt.Errorf("failed to create pod: %w", err)
+	}
+	image := publicImages[0]
+	if options.Image != "" {
+		found, err := internal.FindImage(p.listImages(), options.Image)
+		if err != nil {
+			return internal.Pod{}, fmt.Errorf("failed to create pod: %w", err)
+		}
+		image = found
+	}
+
+	dataDiskSize, expandable := int64(50<<30), int64(500<<30)
+	if options.DataDiskSize > 0 {
+		if options.DataDiskSize > dataDiskSize+expandable {
+			return internal.Pod{}, fmt.Errorf("failed to create pod: data disk size is at most %d GiB", (dataDiskSize+expandable)>>30)
+		}
+		expandable -= max(options.DataDiskSize-dataDiskSize, 0)
+		dataDiskSize = options.DataDiskSize
+	}
+
+	p.nextID++
+	id := fmt.Sprintf("sim-%04d", p.nextID)
+	name := id
+	if options.Name != "" {
+		name = options.Name
+	}
+	now := p.opts.Now()
+	sp := &simPod{
+		pod: internal.Pod{
+			ID:                     id,
+			PoolID:                 p.id,
+			CreateTimestamp:        now.Unix(),
+			DataCenterName:         dataCenter.Name,
+			Name:                   name,
+			GPUModel:               options.GPUModel,
+			GPUCount:               options.GPUCount,
+			CPUModel:               "Simulated CPU",
+			CPUCoreCount:           16 * options.GPUCount,
+			MemorySize:             64 * int64(options.GPUCount) << 30,
+			SystemDiskSize:         30 << 30,
+			DataDiskSize:           dataDiskSize,
+			ExpandableDataDiskSize: expandable,
+			DataDiskMountPath:      "/root/data",
+			PricePerHour:           price * float64(options.GPUCount),
+			Status:                 internal.StatusCreating,
+			ImageID:                image.ID,
+			ImageType:              string(image.Type),
+			Pool:                   p,
+		},
+	}
+	p.provision(sp, now)
+	p.setAutoShutdown(sp, now, options.AutoShutdown, options.AutoShutdownAction)
+	p.pods[id] = sp
+	return sp.pod, nil
+}
+
+func (p *Pool) GetPod(ctx context.Context, podID string) (internal.Pod, error) {
+	if err := ctx.Err(); err != nil {
+		return internal.Pod{}, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	sp, err := p.lookup(podID)
+	if err != nil {
+		return internal.Pod{}, err
+	}
+	return sp.pod, nil
+}
+
+func (p *Pool) ListPods(ctx context.Context, options internal.ListOptions) ([]internal.Pod, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.opts.Now()
+	pods := make([]internal.Pod, 0, len(p.pods))
+	for i := 1; i <= p.nextID; i++ {
+		sp, ok := p.pods[fmt.Sprintf("sim-%04d", i)]
+		if !ok {
+			continue
+		}
+		if p.advance(sp, now) && options.Match(sp.pod) {
+			pods = append(pods, sp.pod)
+		}
+	}
+	return pods, nil
+}
+
+func (p *Pool) DestroyPod(ctx context.Context, podID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	sp, err := p.lookup(podID)
+	if err != nil {
+		return err
+	}
+	p.settle(sp, p.opts.Now())
+	delete(p.pods, podID)
+	return nil
+}
+
+func (p *Pool) StopPod(ctx context.Context, podID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	sp, err := p.lookup(podID)
+	if err != nil {
+		return err
+	}
+	if sp.pod.Status != internal.StatusRunning && sp.pod.Status != internal.StatusError {
+		return fmt.Errorf("failed to stop pod, pod %s is %s", podID, sp.pod.Status)
+	}
+	p.settle(sp, p.opts.Now())
+	sp.pod.Status = internal.StatusStopped
+	return nil
+}
+
+func (p *Pool) StartPod(ctx context.Context, podID string) error {
+	return p.reprovision(ctx, podID, internal.StatusStopped, "start")
+}
+
+func (p *Pool) RestartPod(ctx context.Context, podID string) error {
+	return p.reprovision(ctx, podID, internal.StatusRunning, "restart")
+}
+
+// ListOffers returns an offer for every GPU model with a price
+func (p *Pool) ListOffers(ctx context.Context) ([]internal.Offer, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.offers(), nil
+}
+
+// ListDataCenters returns the single simulated data center
+func (p *Pool) ListDataCenters(ctx context.Context) ([]internal.DataCenter, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	dc := dataCenter
+	dc.PoolID = p.id
+	dc.Stock = make(map[internal.GPUModel]int, len(p.opts.Prices))
+	for model := range p.opts.Prices {
+		dc.Stock[model] = p.available(model)
+	}
+	return []internal.DataCenter{dc}, nil
+}
+
+// offers returns an offer for every GPU model with a price, the caller must hold p.mu
+func (p *Pool) offers() []internal.Offer {
+	offers := make([]internal.Offer, 0, len(p.opts.Prices))
+	for model, price := range p.opts.Prices {
+		offers = append(offers, internal.Offer{
+			PoolID:         p.id,
+			GPUModel:       model,
+			DataCenterID:   dataCenter.ID,
+			DataCenterName: dataCenter.Name,
+			Stock:          p.available(model),
+			PricePerHour:   price,
+		})
+	}
+	return offers
+}
+
+// GetBalance returns the prepaid balance minus the spend so far
+func (p *Pool) GetBalance(ctx context.Context) (internal.Balance, error) {
+	if err := ctx.Err(); err != nil {
+		return internal.Balance{}, err
+	}
+	return internal.Balance{
+		PoolID:   p.id,
+		Amount:   p.opts.Balance - p.Spent(),
+		Currency: "CNY",
+	}, nil
+}
+
+// ListCharges returns the settled runs and the cost of the current runs so far
+func (p *Pool) ListCharges(ctx context.Context, since time.Time) ([]internal.Charge, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.opts.Now()
+	var charges []internal.Charge
+	for i := 1; i <= p.nextID; i++ {
+		sp, ok := p.pods[fmt.Sprintf("sim-%04d", i)]
+		if !ok {
+			continue
+		}
+		if !p.advance(sp, now) || sp.runningSince.IsZero() {
+			continue
+		}
+		charges = append(charges, internal.Charge{
+			PoolID:      p.id,
+			Timestamp:   now.Unix(),
+			PodID:       sp.pod.ID,
+			Description: fmt.Sprintf("%d x %s, running", sp.pod.GPUCount, sp.pod.GPUModel),
+			Amount:      now.Sub(sp.runningSince).Hours() * sp.pod.PricePerHour,
+		})
+	}
+	for i := len(p.charges) - 1; i >= 0; i-- {
+		if p.charges[i].Timestamp >= since.Unix() {
+			charges = append(charges, p.charges[i])
+		}
+	}
+	return charges, nil
+}
+
+// Spent returns the simulated cost of all pods so far, including destroyed ones
+func (p *Pool) Spent() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.opts.Now()
+	spent := p.spent
+	for _, sp := range p.pods {
+		p.advance(sp, now)
+		if !sp.runningSince.IsZero() {
+			spent += now.Sub(sp.runningSince).Hours() * sp.pod.PricePerHour
+		}
+	}
+	return spent
+}
+
+// reprovision sends a pod in the given status back to creating
+func (p *Pool) reprovision(ctx context.Context, podID string, from internal.PodStatus, action string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	sp, err := p.lookup(podID)
+	if err != nil {
+		return err
+	}
+	if sp.pod.Status != from {
+		return fmt.Errorf("failed to %s pod, pod %s is %s", action, podID, sp.pod.Status)
+	}
+	if from == internal.StatusStopped && p.available(sp.pod.GPUModel) < sp.pod.GPUCount {
+		return fmt.Errorf("failed to %s pod: %w for %d x %s", action, internal.ErrOutOfStock, sp.pod.GPUCount, sp.pod.GPUModel)
+	}
+	now := p.opts.Now()
+	p.settle(sp, now)
+	sp.pod.Status = internal.StatusCreating
+	p.provision(sp, now)
+	return nil
+}
+
+// available returns the number of free GPUs of a model, the caller must hold p.mu
+func (p *Pool) available(model internal.GPUModel) int {
+	available := p.opts.Stock
+	for _, sp := range p.pods {
+		if sp.pod.GPUModel == model && sp.pod.Status != internal.StatusStopped {
+			available -= sp.pod.GPUCount
+		}
+	}
+	return available
+}
+
+// lookup returns the pod with its state advanced to now, the caller must hold p.mu
+func (p *Pool) lookup(podID string) (*simPod, error) {
+	sp, ok := p.pods[podID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", internal.ErrPodNotFound, podID)
+	}
+	if !p.advance(sp, p.opts.Now()) {
+		return nil, fmt.Errorf("%w: %s", internal.ErrPodNotFound, podID)
+	}
+	return sp, nil
+}
+
+// provision schedules the end of provisioning, the caller must hold p.mu
+func (p *Pool) provision(sp *simPod, now time.Time) {
+	sp.readyAt = now.Add(p.opts.ProvisionDelay)
+	sp.fails = p.rand.Float64() < p.opts.ProvisionFailureRate
+}
+
+// advance moves a creating pod to running or error once it is due and
+// applies a due auto-shutdown. It returns false if the pod was destroyed by
+// it, the caller must hold p.mu
+func (p *Pool) advance(sp *simPod, now time.Time) bool {
+	if sp.pod.Status == internal.StatusCreating && now.Before(sp.readyAt) {
+		left := sp.readyAt.Sub(now)
+		sp.pod.Progress = 100 - int(100*left/p.opts.ProvisionDelay)
+	}
+	if sp.pod.Status == internal.StatusCreating && !now.Before(sp.readyAt) {
+		if sp.fails {
+			sp.pod.Status = internal.StatusError
+		} else {
+			sp.pod.Status = internal.StatusRunning
+			sp.pod.Progress = 100
+			sp.runningSince = sp.readyAt
+		}
+	}
+
+	shutdownAt := time.Unix(sp.pod.AutoShutdownTimestamp, 0)
+	if sp.pod.AutoShutdownTimestamp == 0 || now.Before(shutdownAt) || sp.pod.Status == internal.StatusStopped {
+		return true
+	}
+	p.settle(sp, shutdownAt)
+	sp.pod.AutoShutdownTimestamp = 0
+	if sp.pod.AutoShutdownAction == internal.AutoShutdownDestroy {
+		delete(p.pods, sp.pod.ID)
+		return false
+	}
+	sp.pod.Status = internal.StatusStopped
+	return true
+}
+
+// SetAutoShutdown shuts the pod down after the given duration from now
+func (p *Pool) SetAutoShutdown(ctx context.Context, podID string, after time.Duration, action internal.AutoShutdownAction) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	sp, err := p.lookup(podID)
+	if err != nil {
+		return err
+	}
+	p.setAutoShutdown(sp, p.opts.Now(), after, action)
+	return nil
+}
+
+// setAutoShutdown sets the auto-shutdown of a pod, the caller must hold p.mu
+func (p *Pool) setAutoShutdown(sp *simPod, now time.Time, after time.Duration, action internal.AutoShutdownAction) {
+	if after <= 0 {
+		sp.pod.AutoShutdownTimestamp = 0
+		sp.pod.AutoShutdownAction = ""
+		return
+	}
+	if action == "" {
+		action = internal.AutoShutdownStop
+	}
+	sp.pod.AutoShutdownTimestamp = now.Add(after).Unix()
+	sp.pod.AutoShutdownAction = action
+}
+
+// settle adds the cost of the current run to the spend, the caller must hold p.mu
+func (p *Pool) settle(sp *simPod, now time.Time) {
+	if sp.runningSince.IsZero() {
+		return
+	}
+	if now.Before(sp.runningSince) {
+		now = sp.runningSince
+	}
+	amount := now.Sub(sp.runningSince).Hours() * sp.pod.PricePerHour
+	p.spent += amount
+	p.charges = append(p.charges, internal.Charge{
+		PoolID:      p.id,
+		Timestamp:   now.Unix(),
+		PodID:       sp.pod.ID,
+		Description: fmt.Sprintf("%d x %s", sp.pod.GPUCount, sp.pod.GPUModel),
+		Amount:      amount,
+	})
+	sp.runningSince = time.Time{}
+}
+
+func (p *Pool) ExpandDataDisk(ctx context.Context, podID string, size int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	sp, err := p.lookup(podID)
+	if err != nil {
+		return err
+	}
+	if sp.pod.ExpandableDataDiskSize == 0 {
+		return fmt.Errorf("the data disk of pod %s cannot grow any more", podID)
+	}
+	if err := internal.CheckDataDiskSize(sp.pod, size); err != nil {
+		return err
+	}
+	sp.pod.ExpandableDataDiskSize -= size - sp.pod.DataDiskSize
+	sp.pod.DataDiskSize = size
+	return nil
+}
@@ -0,0 +1,233 @@
+package sim
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/funstory-ai/gobun/internal"
+)
+
+// clock is a fake time source that tests move forward by hand
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// newTestPool returns a pool with a 10 second provisioning delay driven by the clock
+func newTestPool(t *testing.T, configure func(*Options)) (*Pool, *clock) {
+	t.Helper()
+	c := &clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	opts := DefaultOptions()
+	opts.ProvisionDelay = 10 * time.Second
+	opts.Seed = 1
+	opts.Now = c.Now
+	if configure != nil {
+		configure(&opts)
+	}
+	return NewPool(opts), c
+}
+
+func getPod(t *testing.T, pool *Pool, podID string) internal.Pod {
+	t.Helper()
+	pod, err := pool.GetPod(context.Background(), podID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pod
+}
+
+func TestProvisioning(t *testing.T) {
+	ctx := context.Background()
+	pool, clock := newTestPool(t, nil)
+	pod, err := pool.CreatePod(ctx, internal.PodOptions{GPUModel: internal.GPUModelRTX4090, GPUCount: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pod.Status != internal.StatusCreating {
+		t.Errorf("status after CreatePod = %s, want %s", pod.Status, internal.StatusCreating)
+	}
+
+	clock.Advance(5 * time.Second)
+	pod = getPod(t, pool, pod.ID)
+	if pod.Status != internal.StatusCreating || pod.Progress != 50 {
+		t.Errorf("halfway: status %s, progress %d, want %s and 50", pod.Status, pod.Progress, internal.StatusCreating)
+	}
+
+	clock.Advance(5 * time.Second)
+	pod = getPod(t, pool, pod.ID)
+	if pod.Status != internal.StatusRunning || pod.Progress != 100 {
+		t.Errorf("after the delay: status %s, progress %d, want %s and 100", pod.Status, pod.Progress, internal.StatusRunning)
+	}
+}
+
+func TestFailureRates(t *testing.T) {
+	ctx := context.Background()
+	options := internal.PodOptions{GPUModel: internal.GPUModelRTX4090, GPUCount: 1}
+
+	pool, _ := newTestPool(t, func(o *Options) { o.CreateFailureRate = 1 })
+	if _, err := pool.CreatePod(ctx, options); !errors.Is(err, internal.ErrTransient) {
+		t.Errorf("CreatePod = %v, want %v", err, internal.ErrTransient)
+	}
+	if pods, _ := pool.ListPods(ctx, internal.ListOptions{}); len(pods) != 0 {
+		t.Errorf("a failed CreatePod left %d pods", len(pods))
+	}
+
+	pool, clock := newTestPool(t, func(o *Options) { o.ProvisionFailureRate = 1 })
+	pod, err := pool.CreatePod(ctx, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(10 * time.Second)
+	if pod = getPod(t, pool, pod.ID); pod.Status != internal.StatusError {
+		t.Errorf("status = %s, want %s", pod.Status, internal.StatusError)
+	}
+	if spent := pool.Spent(); spent != 0 {
+		t.Errorf("a pod in error cost %.2f", spent)
+	}
+}
+
+func TestStopStart(t *testing.T) {
+	ctx := context.Background()
+	pool, clock := newTestPool(t, func(o *Options) { o.Stock = 1 })
+	pod, err := pool.CreatePod(ctx, internal.PodOptions{GPUModel: internal.GPUModelRTX4090, GPUCount: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pool.StopPod(ctx, pod.ID); err == nil {
+		t.Error("StopPod of a creating pod succeeded")
+	}
+	clock.Advance(10 * time.Second)
+
+	if err := pool.StartPod(ctx, pod.ID); err == nil {
+		t.Error("StartPod of a running pod succeeded")
+	}
+	if err := pool.StopPod(ctx, pod.ID); err != nil {
+		t.Fatal(err)
+	}
+	if pod = getPod(t, pool, pod.ID); pod.Status != internal.StatusStopped {
+		t.Errorf("status after StopPod = %s, want %s", pod.Status, internal.StatusStopped)
+	}
+
+	// A stopped pod gives its GPU back, so it cannot start once another pod holds it
+	other, err := pool.CreatePod(ctx, internal.PodOptions{GPUModel: internal.GPUModelRTX4090, GPUCount: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pool.StartPod(ctx, pod.ID); !errors.Is(err, internal.ErrOutOfStock) {
+		t.Errorf("StartPod without stock = %v, want %v", err, internal.ErrOutOfStock)
+	}
+	if err := pool.DestroyPod(ctx, other.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := pool.StartPod(ctx, pod.ID); err != nil {
+		t.Fatal(err)
+	}
+	if pod = getPod(t, pool, pod.ID); pod.Status != internal.StatusCreating {
+		t.Errorf("status after StartPod = %s, want %s", pod.Status, internal.StatusCreating)
+	}
+	clock.Advance(10 * time.Second)
+	if pod = getPod(t, pool, pod.ID); pod.Status != internal.StatusRunning {
+		t.Errorf("status after provisioning = %s, want %s", pod.Status, internal.StatusRunning)
+	}
+}
+
+func TestAutoShutdown(t *testing.T) {
+	ctx := context.Background()
+	pool, clock := newTestPool(t, nil)
+	stopped, err := pool.CreatePod(ctx, internal.PodOptions{GPUModel: internal.GPUModelRTX4090, GPUCount: 1, AutoShutdown: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	destroyed, err := pool.CreatePod(ctx, internal.PodOptions{GPUModel: internal.GPUModelRTX4090, GPUCount: 1, AutoShutdown: time.Hour, AutoShutdownAction: internal.AutoShutdownDestroy})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stopped.AutoShutdownAction != internal.AutoShutdownStop {
+		t.Errorf("default auto-shutdown action = %q, want %q", stopped.AutoShutdownAction, internal.AutoShutdownStop)
+	}
+
+	clock.Advance(time.Hour - time.Second)
+	if pod := getPod(t, pool, stopped.ID); pod.Status != internal.StatusRunning {
+		t.Errorf("status before the auto-shutdown = %s, want %s", pod.Status, internal.StatusRunning)
+	}
+
+	clock.Advance(time.Second)
+	pod := getPod(t, pool, stopped.ID)
+	if pod.Status != internal.StatusStopped || pod.AutoShutdownTimestamp != 0 {
+		t.Errorf("after the auto-shutdown: status %s, auto-shutdown %d, want %s and 0", pod.Status, pod.AutoShutdownTimestamp, internal.StatusStopped)
+	}
+	if _, err := pool.GetPod(ctx, destroyed.ID); !errors.Is(err, internal.ErrPodNotFound) {
+		t.Errorf("GetPod after the auto-shutdown = %v, want %v", err, internal.ErrPodNotFound)
+	}
+
+	// Both pods ran from the end of provisioning until the deadline
+	want := 2 * (time.Hour - 10*time.Second).Hours() * 1.98
+	if spent := pool.Spent(); math.Abs(spent-want) > 1e-9 {
+		t.Errorf("spent %.4f, want %.4f", spent, want)
+	}
+}
+
+func TestSpend(t *testing.T) {
+	ctx := context.Background()
+	pool, clock := newTestPool(t, func(o *Options) { o.ProvisionDelay = 0 })
+	start := clock.Now()
+	pod, err := pool.CreatePod(ctx, internal.PodOptions{GPUModel: internal.GPUModelRTX4090, GPUCount: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(time.Hour)
+	if err := pool.StopPod(ctx, pod.ID); err != nil {
+		t.Fatal(err)
+	}
+	// Stopped pods cost nothing
+	clock.Advance(time.Hour)
+	if err := pool.StartPod(ctx, pod.ID); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(30 * time.Minute)
+
+	if spent := pool.Spent(); math.Abs(spent-1.5*3.96) > 1e-9 {
+		t.Errorf("spent %.4f, want %.4f", spent, 1.5*3.96)
+	}
+	balance, err := pool.GetBalance(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(balance.Amount-(100-1.5*3.96)) > 1e-9 {
+		t.Errorf("balance %.4f, want %.4f", balance.Amount, 100-1.5*3.96)
+	}
+
+	charges, err := pool.ListCharges(ctx, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(charges) != 2 {
+		t.Fatalf("got %d charges, want the current run and the settled one", len(charges))
+	}
+	if math.Abs(charges[0].Amount-0.5*3.96) > 1e-9 || math.Abs(charges[1].Amount-3.96) > 1e-9 {
+		t.Errorf("charges %.4f and %.4f, want %.4f and %.4f", charges[0].Amount, charges[1].Amount, 0.5*3.96, 3.96)
+	}
+
+	if err := pool.DestroyPod(ctx, pod.ID); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Hour)
+	if spent := pool.Spent(); math.Abs(spent-1.5*3.96) > 1e-9 {
+		t.Errorf("spent %.4f after DestroyPod, want %.4f", spent, 1.5*3.96)
+	}
+	if charges, _ := pool.ListCharges(ctx, clock.Now()); len(charges) != 0 {
+		t.Errorf("got %d charges since now, want 0", len(charges))
+	}
+}
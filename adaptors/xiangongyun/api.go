@@ -2,9 +2,26 @@ package xiangongyun
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/funstory-ai/gobun/internal"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultBaseURL is the XianGongYun open API endpoint
+	DefaultBaseURL = "https://api.xiangongyun.com"
+
+	// DefaultTimeout bounds a single request when the caller's context has no deadline
+	DefaultTimeout = 60 * time.Second
 )
 
 type Instance struct {
@@ -43,45 +60,224 @@ type Instance struct {
 	ImagePrice             float64 `json:"image_price"`
 	ImageSave              bool    `json:"image_save"`
 	BasePrice              float64 `json:"base_price"`
-	AutoShutdown           int     `json:"auto_shutdown"`
-	AutoShutdownAction     int     `json:"auto_shutdown_action"`
+	// AutoShutdown is the Unix time of the automatic shutdown, 0 when it is off
+	AutoShutdown int64 `json:"auto_shutdown"`
+	// AutoShutdownAction is 0 to stop the instance and 1 to destroy it
+	AutoShutdownAction int `json:"auto_shutdown_action"`
+}
+
+// GPUStock is the stock and price of a GPU model in a data center
+type GPUStock struct {
+	GPUModel       string  `json:"gpu_model"`
+	DataCenterID   int     `json:"data_center_id"`
+	DataCenterName string  `json:"data_center_name"`
+	Stock          int     `json:"stock"`
+	PricePerHour   float64 `json:"price_per_hour"`
+	CPUCoreCount   int     `json:"cpu_core_count"`
+	MemorySize     int64   `json:"memory_size"`
+	DataDiskSize   int64   `json:"data_disk_size"`
+}
+
+// DataCenter is a data center as returned by the XianGongYun open API
+type DataCenter struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Region string `json:"region"`
+}
+
+// Bill is a charge as returned by the XianGongYun open API
+type Bill struct {
+	ID              string  `json:"id"`
+	InstanceID      string  `json:"instance_id"`
+	Description     string  `json:"description"`
+	Amount          float64 `json:"amount"`
+	CreateTimestamp int64   `json:"create_timestamp"`
+}
+
+// Image is a public or private image as returned by the XianGongYun open API
+type Image struct {
+	ID              string  `json:"id"`
+	Name            string  `json:"name"`
+	Size            int64   `json:"size"`
+	Price           float64 `json:"price"`
+	Status          string  `json:"status"`
+	CreateTimestamp int64   `json:"create_timestamp"`
+}
+
+// Response is the envelope of every XianGongYun open API response
+type Response struct {
+	Code      int             `json:"code"`
+	Msg       string          `json:"msg"`
+	Success   bool            `json:"success"`
+	RequestID string          `json:"request_id"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Options configures the API client
+type Options struct {
+	// BaseURL is the API endpoint, defaults to DefaultBaseURL
+	BaseURL string
+	// Transport sends the requests, defaults to http.DefaultTransport.
+	// Set it to a cassette.Recorder or cassette.Player to record or replay calls
+	Transport http.RoundTripper
+	// Retry is applied to idempotent calls and to calls the server never saw
+	Retry RetryPolicy
+	// RateLimit is the average number of calls per second, RateBurst the
+	// number of calls that may be sent at once, RateLimit <= 0 disables it
+	RateLimit float64
+	RateBurst int
+}
+
+// DefaultOptions retries with DefaultRetryPolicy and sends at most 5 calls per second
+func DefaultOptions() Options {
+	return Options{
+		Retry:     DefaultRetryPolicy,
+		RateLimit: 5,
+		RateBurst: 5,
+	}
 }
 
 type API struct {
 	authorization string
+	baseURL       string
 	client        *http.Client
+	retry         RetryPolicy
+	limiter       *rateLimiter
 }
 
 func InitAPI(authorization string) *API {
+	return NewAPI(authorization, DefaultOptions())
+}
+
+func NewAPI(authorization string, opts Options) *API {
+	if opts.BaseURL == "" {
+		opts.BaseURL = DefaultBaseURL
+	}
+	if opts.Retry.MaxAttempts < 1 {
+		opts.Retry.MaxAttempts = 1
+	}
 	return &API{
 		authorization: authorization,
-		client:        &http.Client{},
+		baseURL:       strings.TrimSuffix(opts.BaseURL, "/"),
+		client:        &http.Client{Transport: opts.Transport},
+		retry:         opts.Retry,
+		limiter:       newRateLimiter(opts.RateLimit, opts.RateBurst),
 	}
 }
 
-func (api *API) DoRequest(method string, path string, body interface{}) ([]byte, error) {
-	bodyBytes, err := json.Marshal(body)
-	if err != nil {
-		return nil, err
+// DoRequest sends a request and decodes the data of the response envelope into out.
+// A non-2xx HTTP status, a code other than 200 or success=false is returned as an *APIError.
+// GET requests are retried, other methods only when the server cannot have seen them,
+// use DoIdempotentRequest for calls that are safe to repeat
+func (api *API) DoRequest(ctx context.Context, method string, path string, body interface{}, out interface{}) error {
+	return api.do(ctx, method, path, body, out, method == http.MethodGet)
+}
+
+// DoIdempotentRequest is DoRequest for calls that have the same effect when
+// sent twice, e.g. destroying an instance, they are retried on any transient failure
+func (api *API) DoIdempotentRequest(ctx context.Context, method string, path string, body interface{}, out interface{}) error {
+	return api.do(ctx, method, path, body, out, true)
+}
+
+func (api *API) do(ctx context.Context, method string, path string, body interface{}, out interface{}, idempotent bool) error {
+	for attempt := 1; ; attempt++ {
+		if err := api.limiter.wait(ctx); err != nil {
+			return err
+		}
+		err := api.send(ctx, method, path, body, out)
+		if err == nil || attempt >= api.retry.MaxAttempts || !retryable(err, idempotent) {
+			return err
+		}
+		delay := api.retry.backoff(attempt)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > delay {
+			delay = apiErr.RetryAfter
+		}
+		logrus.Debugf("%s %s failed, retrying in %s: %v", method, path, delay.Round(time.Millisecond), err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			logrus.Debugf("%s %s abandoned while waiting to retry: %v", method, path, err)
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// send makes a single attempt of a request
+func (api *API) send(ctx context.Context, method string, path string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(bodyBytes)
 	}
-	url := "https://api.xiangongyun.com" + path
-	req, err := http.NewRequest(method, url, bytes.NewBuffer(bodyBytes))
+	reqCtx := ctx
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		reqCtx, cancel = context.WithTimeout(ctx, DefaultTimeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(reqCtx, method, api.baseURL+path, reader)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", api.authorization)
 	resp, err := api.client.Do(req)
 	if err != nil {
-		return nil, err
+		if ctx.Err() != nil {
+			return err
+		}
+		return fmt.Errorf("%w: %w", internal.ErrTransient, err)
 	}
 	defer resp.Body.Close()
 
-	// Read the response body to bytes
-	bodyBytes, err = io.ReadAll(resp.Body)
+	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	apiErr := &APIError{
+		Method:     method,
+		Path:       path,
+		HTTPStatus: resp.StatusCode,
+		RequestID:  resp.Header.Get("X-Request-Id"),
+		RetryAfter: retryAfter(resp.Header.Get("Retry-After")),
+	}
+
+	var response Response
+	if err := json.Unmarshal(bodyBytes, &response); err != nil {
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+			return apiErr
+		}
+		return fmt.Errorf("%s %s: decoding response with http status %d failed: %w", method, path, resp.StatusCode, err)
+	}
+	if response.RequestID != "" {
+		apiErr.RequestID = response.RequestID
 	}
+	apiErr.Code = response.Code
+	apiErr.Message = response.Msg
+	if resp.StatusCode < 200 || resp.StatusCode > 299 || response.Code != codeOK || !response.Success {
+		return apiErr
+	}
+	if out == nil || len(response.Data) == 0 || string(response.Data) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(response.Data, out); err != nil {
+		return fmt.Errorf("%s %s: decoding response data failed: %w", method, path, err)
+	}
+	return nil
+}
 
-	return bodyBytes, nil
+// retryAfter parses a Retry-After header given in seconds
+func retryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
 }
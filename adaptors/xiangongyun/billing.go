@@ -0,0 +1,57 @@
+package xiangongyun
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/funstory-ai/gobun/internal"
+)
+
+// currency is the currency of all XianGongYun amounts
+const currency = "CNY"
+
+func (p *Pool) GetBalance(ctx context.Context) (internal.Balance, error) {
+	var data struct {
+		Balance float64 `json:"balance"`
+	}
+	if err := p.api.DoRequest(ctx, "GET", "/open/balance", nil, &data); err != nil {
+		return internal.Balance{}, fmt.Errorf("failed to get balance: %w", err)
+	}
+	return internal.Balance{
+		PoolID:   p.id,
+		Amount:   data.Balance,
+		Currency: currency,
+	}, nil
+}
+
+func (p *Pool) ListCharges(ctx context.Context, since time.Time) ([]internal.Charge, error) {
+	query := url.Values{}
+	query.Set("start_timestamp", strconv.FormatInt(since.Unix(), 10))
+	var data struct {
+		List []Bill `json:"list"`
+	}
+	if err := p.api.DoRequest(ctx, "GET", "/open/bills?"+query.Encode(), nil, &data); err != nil {
+		return nil, fmt.Errorf("failed to list charges: %w", err)
+	}
+	charges := make([]internal.Charge, 0, len(data.List))
+	for _, bill := range data.List {
+		if bill.CreateTimestamp < since.Unix() {
+			continue
+		}
+		charges = append(charges, internal.Charge{
+			PoolID:      p.id,
+			Timestamp:   bill.CreateTimestamp,
+			PodID:       bill.InstanceID,
+			Description: bill.Description,
+			Amount:      bill.Amount,
+		})
+	}
+	sort.SliceStable(charges, func(i, j int) bool {
+		return charges[i].Timestamp > charges[j].Timestamp
+	})
+	return charges, nil
+}
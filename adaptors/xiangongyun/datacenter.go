@@ -0,0 +1,42 @@
+package xiangongyun
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/funstory-ai/gobun/internal"
+)
+
+// ListDataCenters returns the data centers with their current GPU stock
+func (p *Pool) ListDataCenters(ctx context.Context) ([]internal.DataCenter, error) {
+	var data struct {
+		List []DataCenter `json:"list"`
+	}
+	if err := p.api.DoRequest(ctx, "GET", "/open/data_centers", nil, &data); err != nil {
+		return nil, fmt.Errorf("failed to list data centers: %w", err)
+	}
+	offers, err := p.ListOffers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dataCenters := make([]internal.DataCenter, len(data.List))
+	for i, dc := range data.List {
+		id := strconv.Itoa(dc.ID)
+		stock := make(map[internal.GPUModel]int)
+		for _, offer := range offers {
+			if offer.DataCenterID == id {
+				stock[offer.GPUModel] += offer.Stock
+			}
+		}
+		dataCenters[i] = internal.DataCenter{
+			ID:     id,
+			PoolID: p.id,
+			Name:   dc.Name,
+			Region: dc.Region,
+			Stock:  stock,
+		}
+	}
+	return dataCenters, nil
+}
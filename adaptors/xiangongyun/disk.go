@@ -0,0 +1,28 @@
+package xiangongyun
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/funstory-ai/gobun/internal"
+)
+
+// ExpandDataDisk grows the data disk of an instance, the call is idempotent
+// since it sends the total size
+func (p *Pool) ExpandDataDisk(ctx context.Context, podID string, size int64) error {
+	pod, err := p.GetPod(ctx, podID)
+	if err != nil {
+		return err
+	}
+	if err := internal.CheckDataDiskSize(pod, size); err != nil {
+		return err
+	}
+	payload := map[string]interface{}{
+		"id":   podID,
+		"size": size,
+	}
+	if err := p.api.DoIdempotentRequest(ctx, "POST", "/open/instance/expand_data_disk", payload, nil); err != nil {
+		return fmt.Errorf("failed to expand data disk of pod %s: %w", podID, err)
+	}
+	return nil
+}
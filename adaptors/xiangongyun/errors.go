@@ -0,0 +1,89 @@
+package xiangongyun
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/funstory-ai/gobun/internal"
+)
+
+// codeOK is the envelope code of a successful response
+const codeOK = 200
+
+// balanceMessages and stockMessages identify failures that share a generic
+// envelope code, matched case-insensitively against msg
+var (
+	balanceMessages = []string{"余额不足", "insufficient balance", "balance not enough"}
+	stockMessages   = []string{"库存不足", "资源不足", "out of stock", "no available gpu", "insufficient gpu"}
+)
+
+// APIError is a failed XianGongYun open API call, it unwraps to one of the
+// errors of package internal when the failure is recognized
+type APIError struct {
+	Method     string
+	Path       string
+	HTTPStatus int
+	// Code and Message are the envelope code and msg, zero when the
+	// response had no envelope
+	Code      int
+	Message   string
+	RequestID string
+	// RetryAfter is the delay asked for by the server, zero when it did not ask
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s: ", e.Method, e.Path)
+	if e.Code != 0 {
+		fmt.Fprintf(&b, "response code: %d", e.Code)
+	} else {
+		fmt.Fprintf(&b, "http status: %d", e.HTTPStatus)
+	}
+	if e.Message != "" {
+		fmt.Fprintf(&b, " %s", e.Message)
+	}
+	if e.RequestID != "" {
+		fmt.Fprintf(&b, " (request id: %s)", e.RequestID)
+	}
+	return b.String()
+}
+
+// Unwrap returns the kind of the failure, nil when it is not recognized
+func (e *APIError) Unwrap() error {
+	if kind := errorKind(e.Code, e.Message); kind != nil {
+		return kind
+	}
+	return errorKind(e.HTTPStatus, "")
+}
+
+// errorKind maps an envelope code or HTTP status and message onto the errors
+// of package internal, nil when they are not recognized
+func errorKind(code int, msg string) error {
+	lower := strings.ToLower(msg)
+	for _, m := range balanceMessages {
+		if strings.Contains(lower, m) {
+			return internal.ErrInsufficientBalance
+		}
+	}
+	for _, m := range stockMessages {
+		if strings.Contains(lower, m) {
+			return internal.ErrOutOfStock
+		}
+	}
+	switch {
+	case code == 401 || code == 403:
+		return internal.ErrUnauthorized
+	case code == 402:
+		return internal.ErrInsufficientBalance
+	case code == 404:
+		return internal.ErrPodNotFound
+	case code == 429:
+		return internal.ErrRateLimited
+	case code >= 500 && code < 600:
+		return internal.ErrTransient
+	default:
+		return nil
+	}
+}
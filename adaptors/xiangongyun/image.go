@@ -0,0 +1,98 @@
+package xiangongyun
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/funstory-ai/gobun/internal"
+)
+
+// defaultImageID is the public PyTorch image used when no image is given
+const defaultImageID = "2f98442f-1e6e-4531-8b92-88a09d5d8a20"
+
+// ListImages returns the public images followed by the private images of the account
+func (p *Pool) ListImages(ctx context.Context) ([]internal.Image, error) {
+	var images []internal.Image
+	for _, source := range []struct {
+		path      string
+		imageType internal.ImageType
+	}{
+		{"/open/public_images", internal.ImageTypePublic},
+		{"/open/images", internal.ImageTypePrivate},
+	} {
+		var data struct {
+			List []Image `json:"list"`
+		}
+		if err := p.api.DoRequest(ctx, "GET", source.path, nil, &data); err != nil {
+			return nil, fmt.Errorf("failed to list %s images: %w", source.imageType, err)
+		}
+		for _, image := range data.List {
+			images = append(images, p.toImage(image, source.imageType))
+		}
+	}
+	return images, nil
+}
+
+// SaveImage saves a pod as a private image, the pod should be stopped first
+// so that the image is consistent
+func (p *Pool) SaveImage(ctx context.Context, podID string, name string) (internal.Image, error) {
+	payload := map[string]interface{}{
+		"id":         podID,
+		"image_name": name,
+	}
+	var data struct {
+		ImageID string `json:"image_id"`
+	}
+	// Not retried beyond what DoRequest deems safe, a repeated save would
+	// store and bill a second image
+	if err := p.api.DoRequest(ctx, "POST", "/open/instance/save_image", payload, &data); err != nil {
+		return internal.Image{}, fmt.Errorf("failed to save pod %s as image: %w", podID, err)
+	}
+	return internal.Image{
+		ID:     data.ImageID,
+		PoolID: p.id,
+		Name:   name,
+		Type:   internal.ImageTypePrivate,
+		Status: "saving",
+	}, nil
+}
+
+func (p *Pool) DestroyImage(ctx context.Context, imageID string) error {
+	payload := map[string]interface{}{
+		"id": imageID,
+	}
+	if err := p.api.DoIdempotentRequest(ctx, "POST", "/open/image/destroy", payload, nil); err != nil {
+		return fmt.Errorf("failed to delete image %s: %w", imageID, err)
+	}
+	return nil
+}
+
+// resolveImage returns the ID and type of the image to deploy, ref is an
+// image ID or name and an empty ref is the default image
+func (p *Pool) resolveImage(ctx context.Context, ref string) (string, internal.ImageType, error) {
+	if ref == "" || ref == defaultImageID {
+		return defaultImageID, internal.ImageTypePublic, nil
+	}
+	images, err := p.ListImages(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	image, err := internal.FindImage(images, ref)
+	if err != nil {
+		return "", "", err
+	}
+	return image.ID, image.Type, nil
+}
+
+func (p *Pool) toImage(image Image, imageType internal.ImageType) internal.Image {
+	return internal.Image{
+		ID:              image.ID,
+		PoolID:          p.id,
+		Name:            image.Name,
+		Type:            imageType,
+		Size:            image.Size,
+		PricePerHour:    image.Price,
+		Status:          image.Status,
+		CreateTimestamp: image.CreateTimestamp,
+	}
+}
@@ -1,25 +1,82 @@
 package xiangongyun
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
 
 	"github.com/funstory-ai/gobun/internal"
+	"github.com/funstory-ai/gobun/internal/utils/cassette"
 )
 
 const (
 	PoolID = "xiangongyun"
+
+	// EnvToken is the environment variable holding the XianGongYun access token
+	EnvToken = "XGY_TOKEN"
+	// EnvBaseURL overrides the API endpoint
+	EnvBaseURL = "XGY_API_URL"
+	// EnvRecord names a cassette file that every API call is recorded to
+	EnvRecord = "XGY_RECORD"
+	// EnvReplay names a cassette file that answers the API calls instead of
+	// the real API, no token is needed then
+	EnvReplay = "XGY_REPLAY"
+
+	// instancesPageSize is the number of instances fetched per request
+	instancesPageSize = 50
+	// maxInstancePages bounds listing, 100 pages are far more instances
+	// than an account has
+	maxInstancePages = 100
 )
 
+func init() {
+	internal.RegisterPool(PoolID, newPoolFromEnv)
+}
+
+// newPoolFromEnv creates the pool with the access token and options from the environment
+func newPoolFromEnv() (internal.Pool, error) {
+	opts := DefaultOptions()
+	opts.BaseURL = os.Getenv(EnvBaseURL)
+	token := os.Getenv(EnvToken)
+
+	if file := os.Getenv(EnvReplay); file != "" {
+		player, err := cassette.Load(file)
+		if err != nil {
+			return nil, err
+		}
+		opts.Transport = player
+		// Replays are instant, waiting between attempts only slows them down
+		opts.RateLimit = 0
+		opts.Retry.BaseDelay = 0
+		if token == "" {
+			token = cassette.Redacted
+		}
+	} else if file := os.Getenv(EnvRecord); file != "" {
+		opts.Transport = cassette.NewRecorder(file, nil)
+	}
+
+	if token == "" {
+		return nil, fmt.Errorf("environment variable %s is not set", EnvToken)
+	}
+	return NewPoolWithOptions("Bearer "+token, opts), nil
+}
+
 type Pool struct {
 	api *API
 	id  string
 }
 
 func NewPool(authorization string) *Pool {
+	return NewPoolWithOptions(authorization, DefaultOptions())
+}
+
+// NewPoolWithOptions creates a pool whose API client uses opts
+func NewPoolWithOptions(authorization string, opts Options) *Pool {
 	return &Pool{
-		api: InitAPI(authorization),
+		api: NewAPI(authorization, opts),
 		id:  PoolID,
 	}
 }
@@ -28,172 +85,245 @@ func (p *Pool) ID() string {
 	return p.id
 }
 
-func (p *Pool) ListPods() ([]internal.Pod, error) {
-	result, err := p.api.DoRequest("GET", "/open/instances", nil)
+func (p *Pool) ListPods(ctx context.Context, options internal.ListOptions) ([]internal.Pod, error) {
+	instances, err := p.listInstances(ctx, options)
 	if err != nil {
 		return nil, err
 	}
-	var response struct {
-		Code int `json:"code"`
-		Data struct {
-			List []Instance `json:"list"`
-		} `json:"data"`
-	}
-	reader := bytes.NewReader(result)
-	if err := json.NewDecoder(reader).Decode(&response); err != nil {
-		return nil, err
-	}
-	pods := make([]internal.Pod, len(response.Data.List))
-	for i, instance := range response.Data.List {
-		pods[i] = internal.Pod{
-			ID:                     instance.ID,
-			PoolID:                 p.id,
-			CreateTimestamp:        instance.CreateTimestamp,
-			DataCenterName:         instance.DataCenterName,
-			Name:                   instance.Name,
-			GPUModel:               internal.GPUModel(instance.GPUModel),
-			GPUCount:               instance.GPUUsed,
-			CPUModel:               instance.CPUModel,
-			CPUCoreCount:           instance.CPUCoreCount,
-			MemorySize:             instance.MemorySize,
-			SystemDiskSize:         instance.SystemDiskSize,
-			DataDiskSize:           instance.DataDiskSize,
-			ExpandableDataDiskSize: instance.ExpandableDataDiskSize,
-			DataDiskMountPath:      instance.DataDiskMountPath,
-			PricePerHour:           instance.PricePerHour,
-			SSHDomain:              instance.SSHDomain,
-			SSHKey:                 instance.SSHKey,
-			SSHPort:                instance.SSHPort,
-			SSHUser:                instance.SSHUser,
-			Password:               instance.Password,
-			Status:                 instance.Status,
-			ImageID:                instance.ImageID,
-			ImageType:              instance.ImageType,
-			ImageSave:              instance.ImageSave,
-			Pool:                   p,
+	pods := make([]internal.Pod, 0, len(instances))
+	for _, instance := range instances {
+		if pod := p.toPod(instance); options.Match(pod) {
+			pods = append(pods, pod)
 		}
 	}
 	return pods, nil
 }
 
-func (p *Pool) GetPod(id string) (internal.Pod, error) {
-	result, err := p.api.DoRequest("GET", "/open/instance/"+id, nil)
-	if err != nil {
-		return internal.Pod{}, err
-	}
-
-	var response struct {
-		Code int      `json:"code"`
-		Data Instance `json:"data"`
+// listInstances fetches every page of instances. The API filters by status
+// if a single one is asked for, ListPods applies the other filters.
+func (p *Pool) listInstances(ctx context.Context, options internal.ListOptions) ([]Instance, error) {
+	var instances []Instance
+	seen := make(map[string]bool)
+	for page := 1; page <= maxInstancePages; page++ {
+		query := url.Values{
+			"page":      {strconv.Itoa(page)},
+			"page_size": {strconv.Itoa(instancesPageSize)},
+		}
+		if len(options.Statuses) == 1 {
+			query.Set("status", string(options.Statuses[0]))
+		}
+		var data struct {
+			List  []Instance `json:"list"`
+			Total int        `json:"total"`
+		}
+		if err := p.api.DoRequest(ctx, "GET", "/open/instances?"+query.Encode(), nil, &data); err != nil {
+			return nil, err
+		}
+		// An API that ignores paging returns the first page again
+		if len(data.List) == 0 || seen[data.List[0].ID] {
+			return instances, nil
+		}
+		for _, instance := range data.List {
+			seen[instance.ID] = true
+		}
+		instances = append(instances, data.List...)
+		// A short page is the last one, as is reaching the total if given
+		if len(data.List) < instancesPageSize || (data.Total > 0 && len(instances) >= data.Total) {
+			return instances, nil
+		}
 	}
+	return nil, fmt.Errorf("failed to list instances: more than %d pages", maxInstancePages)
+}
 
-	if err := json.NewDecoder(bytes.NewReader(result)).Decode(&response); err != nil {
+func (p *Pool) GetPod(ctx context.Context, id string) (internal.Pod, error) {
+	var instance Instance
+	if err := p.api.DoRequest(ctx, "GET", "/open/instance/"+url.PathEscape(id), nil, &instance); err != nil {
 		return internal.Pod{}, err
 	}
+	if instance.ID == "" {
+		return internal.Pod{}, fmt.Errorf("%w: %s", internal.ErrPodNotFound, id)
+	}
+	return p.toPod(instance), nil
+}
 
-	return internal.Pod{
-		ID:                     response.Data.ID,
+func (p *Pool) toPod(instance Instance) internal.Pod {
+	pod := internal.Pod{
+		ID:                     instance.ID,
 		PoolID:                 p.id,
-		CreateTimestamp:        response.Data.CreateTimestamp,
-		DataCenterName:         response.Data.DataCenterName,
-		Name:                   response.Data.Name,
-		GPUModel:               internal.GPUModel(response.Data.GPUModel),
-		GPUCount:               response.Data.GPUUsed,
-		CPUModel:               response.Data.CPUModel,
-		CPUCoreCount:           response.Data.CPUCoreCount,
-		MemorySize:             response.Data.MemorySize,
-		SystemDiskSize:         response.Data.SystemDiskSize,
-		DataDiskSize:           response.Data.DataDiskSize,
-		ExpandableDataDiskSize: response.Data.ExpandableDataDiskSize,
-		DataDiskMountPath:      response.Data.DataDiskMountPath,
-		PricePerHour:           response.Data.PricePerHour,
-		SSHDomain:              response.Data.SSHDomain,
-		SSHKey:                 response.Data.SSHKey,
-		SSHPort:                response.Data.SSHPort,
-		SSHUser:                response.Data.SSHUser,
-		Password:               response.Data.Password,
-		Status:                 response.Data.Status,
-		ImageID:                response.Data.ImageID,
-		ImageType:              response.Data.ImageType,
-		ImageSave:              response.Data.ImageSave,
+		CreateTimestamp:        instance.CreateTimestamp,
+		DataCenterName:         instance.DataCenterName,
+		Name:                   instance.Name,
+		GPUModel:               GPUModelFromProvider(instance.GPUModel),
+		GPUCount:               instance.GPUUsed,
+		CPUModel:               instance.CPUModel,
+		CPUCoreCount:           instance.CPUCoreCount,
+		MemorySize:             instance.MemorySize,
+		SystemDiskSize:         instance.SystemDiskSize,
+		DataDiskSize:           instance.DataDiskSize,
+		ExpandableDataDiskSize: instance.ExpandableDataDiskSize,
+		DataDiskMountPath:      instance.DataDiskMountPath,
+		PricePerHour:           instance.PricePerHour,
+		SSHDomain:              instance.SSHDomain,
+		SSHKey:                 instance.SSHKey,
+		SSHPort:                instance.SSHPort,
+		SSHUser:                instance.SSHUser,
+		Password:               instance.Password,
+		Status:                 internal.PodStatus(instance.Status),
+		Progress:               instance.Progress,
+		ImageID:                instance.ImageID,
+		ImageType:              instance.ImageType,
+		ImageSave:              instance.ImageSave,
+		WebURL:                 instance.WebURL,
+		JupyterURL:             instance.JupyterURL,
+		JupyterToken:           instance.JupyterToken,
 		Pool:                   p,
-	}, nil
+	}
+	if instance.AutoShutdown > 0 {
+		pod.AutoShutdownTimestamp = instance.AutoShutdown
+		pod.AutoShutdownAction = autoShutdownAction(instance.AutoShutdownAction)
+	}
+	return pod
 }
 
-func GPUModelMapping(gpuModel internal.GPUModel) (string, error) {
-	switch gpuModel {
-	case internal.GPUModelRTX4090:
-		return "NVIDIA GeForce RTX 4090", nil
-	case internal.GPUModelRTX4090_D:
-		return "NVIDIA GeForce RTX 4090 D", nil
-	default:
-		return "", fmt.Errorf("unsupported gpu model: %s", gpuModel)
+// ListOffers returns the GPU stock of every data center
+func (p *Pool) ListOffers(ctx context.Context) ([]internal.Offer, error) {
+	var data struct {
+		List []GPUStock `json:"list"`
 	}
+	if err := p.api.DoRequest(ctx, "GET", "/open/gpus", nil, &data); err != nil {
+		return nil, fmt.Errorf("failed to list offers: %w", err)
+	}
+	offers := make([]internal.Offer, len(data.List))
+	for i, gpu := range data.List {
+		offers[i] = internal.Offer{
+			PoolID:         p.id,
+			GPUModel:       GPUModelFromProvider(gpu.GPUModel),
+			DataCenterID:   strconv.Itoa(gpu.DataCenterID),
+			DataCenterName: gpu.DataCenterName,
+			Stock:          gpu.Stock,
+			PricePerHour:   gpu.PricePerHour,
+			CPUCoreCount:   gpu.CPUCoreCount,
+			MemorySize:     gpu.MemorySize,
+			DataDiskSize:   gpu.DataDiskSize,
+		}
+	}
+	return offers, nil
 }
 
-func (p *Pool) CreatePod(options internal.PodOptions) (internal.Pod, error) {
+// gpuNames are the XianGongYun names of the GPU models
+var gpuNames = internal.NewGPUNames(map[internal.GPUModel]string{
+	internal.GPUModelRTX4090:   "NVIDIA GeForce RTX 4090",
+	internal.GPUModelRTX4090_D: "NVIDIA GeForce RTX 4090 D",
+	internal.GPUModelRTX3090:   "NVIDIA GeForce RTX 3090",
+	internal.GPUModelA100_40G:  "NVIDIA A100-PCIE-40GB",
+	internal.GPUModelA100_80G:  "NVIDIA A100-SXM4-80GB",
+	internal.GPUModelA800_80G:  "NVIDIA A800-SXM4-80GB",
+	internal.GPUModelH100_80G:  "NVIDIA H100 80GB HBM3",
+	internal.GPUModelL40S:      "NVIDIA L40S",
+})
+
+// GPUModelFromProvider returns the GPU model of a XianGongYun GPU name,
+// unknown names are kept as they are
+func GPUModelFromProvider(name string) internal.GPUModel {
+	return gpuNames.Model(name)
+}
+
+// GPUModelMapping returns the XianGongYun GPU name of a GPU model
+func GPUModelMapping(gpuModel internal.GPUModel) (string, error) {
+	return gpuNames.Name(gpuModel)
+}
+
+func (p *Pool) CreatePod(ctx context.Context, options internal.PodOptions) (internal.Pod, error) {
 	xgyGPUModel, err := GPUModelMapping(options.GPUModel)
 	if err != nil {
 		return internal.Pod{}, err
 	}
+	imageID, imageType, err := p.resolveImage(ctx, options.Image)
+	if err != nil {
+		return internal.Pod{}, fmt.Errorf("failed to create pod: %w", err)
+	}
+	offers, err := p.ListOffers(ctx)
+	if err != nil {
+		return internal.Pod{}, fmt.Errorf("failed to create pod: %w", err)
+	}
+	offer, err := internal.ChooseOffer(offers, options)
+	if err != nil {
+		return internal.Pod{}, fmt.Errorf("failed to create pod: %w", err)
+	}
+	dataCenterID, err := strconv.Atoi(offer.DataCenterID)
+	if err != nil {
+		return internal.Pod{}, fmt.Errorf("failed to create pod: invalid data center id %q", offer.DataCenterID)
+	}
 
 	// Prepare request payload
 	payload := map[string]interface{}{
 		"gpu_model":      xgyGPUModel,
 		"gpu_count":      options.GPUCount,
-		"data_center_id": 1, // todo: You might want to make this configurable
-		"image":          "2f98442f-1e6e-4531-8b92-88a09d5d8a20",
-		"image_type":     "public",
+		"data_center_id": dataCenterID,
+		"image":          imageID,
+		"image_type":     string(imageType),
 	}
-
-	result, err := p.api.DoRequest("POST", "/open/instance/deploy", payload)
-	if err != nil {
-		return internal.Pod{}, err
+	if options.Name != "" {
+		payload["name"] = options.Name
 	}
-
-	// Parse response
-	var response struct {
-		Code int `json:"code"`
-		Data struct {
-			ID string `json:"id"`
-		} `json:"data"`
+	if options.DataDiskSize > 0 {
+		payload["data_disk_size"] = options.DataDiskSize
 	}
-	if err := json.NewDecoder(bytes.NewReader(result)).Decode(&response); err != nil {
-		return internal.Pod{}, err
+	if options.AutoShutdown > 0 {
+		shutdown, err := autoShutdownPayload(time.Now(), options.AutoShutdown, options.AutoShutdownAction)
+		if err != nil {
+			return internal.Pod{}, err
+		}
+		for key, value := range shutdown {
+			payload[key] = value
+		}
 	}
 
-	if response.Code != 200 {
-		return internal.Pod{}, fmt.Errorf("failed to create pod, response code: %d", response.Code)
+	var data struct {
+		ID string `json:"id"`
 	}
-	pod, err := p.GetPod(response.Data.ID)
-	if err != nil {
-		return internal.Pod{}, err
+	if err := p.api.DoRequest(ctx, "POST", "/open/instance/deploy", payload, &data); err != nil {
+		return internal.Pod{}, fmt.Errorf("failed to create pod: %w", err)
 	}
-	return pod, nil
+	if data.ID == "" {
+		return internal.Pod{}, fmt.Errorf("failed to create pod: deploy response has no instance id")
+	}
+	return internal.GetCreatedPod(ctx, p, data.ID)
 }
 
-func (p *Pool) DestroyPod(podID string) error {
+func (p *Pool) DestroyPod(ctx context.Context, podID string) error {
+	// A destroyed instance stays destroyed, so the call is retried like a GET
 	payload := map[string]interface{}{
 		"id": podID,
 	}
-
-	result, err := p.api.DoRequest("POST", "/open/instance/shutdown_destroy", payload)
-	if err != nil {
-		return err
+	if err := p.api.DoIdempotentRequest(ctx, "POST", "/open/instance/shutdown_destroy", payload, nil); err != nil {
+		return fmt.Errorf("failed to destroy pod %s: %w", podID, err)
 	}
+	return nil
+}
 
-	var response struct {
-		Code    int    `json:"code"`
-		Msg     string `json:"msg"`
-		Success bool   `json:"success"`
-	}
-	if err := json.NewDecoder(bytes.NewReader(result)).Decode(&response); err != nil {
-		return err
-	}
+func (p *Pool) StopPod(ctx context.Context, podID string) error {
+	return p.instanceAction(ctx, "/open/instance/shutdown", podID, "stop")
+}
 
-	if response.Code != 200 {
-		return fmt.Errorf("failed to destroy pod, response code: %d %s", response.Code, response.Msg)
+func (p *Pool) StartPod(ctx context.Context, podID string) error {
+	return p.instanceAction(ctx, "/open/instance/boot", podID, "start")
+}
+
+func (p *Pool) RestartPod(ctx context.Context, podID string) error {
+	return p.instanceAction(ctx, "/open/instance/restart", podID, "restart")
+}
+
+// instanceAction posts an instance ID to one of the lifecycle endpoints. It
+// is only retried when the server cannot have seen the call, a restart sent
+// twice after a timeout would reboot the pod twice.
+func (p *Pool) instanceAction(ctx context.Context, path string, podID string, action string) error {
+	payload := map[string]interface{}{
+		"id": podID,
 	}
 
+	if err := p.api.DoRequest(ctx, "POST", path, payload, nil); err != nil {
+		return fmt.Errorf("failed to %s pod %s: %w", action, podID, err)
+	}
 	return nil
 }
@@ -0,0 +1,84 @@
+package xiangongyun_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/funstory-ai/gobun/adaptors/xiangongyun"
+	"github.com/funstory-ai/gobun/internal"
+)
+
+// instancesServer serves count instances, pages of them like the API or,
+// if ignorePaging is set, all of them on every page and without a total
+func instancesServer(t *testing.T, count int, ignorePaging bool) (*httptest.Server, *int) {
+	t.Helper()
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		pageSize, _ := strconv.Atoi(r.URL.Query().Get("page_size"))
+		start, end := (page-1)*pageSize, page*pageSize
+		if ignorePaging {
+			start, end = 0, count
+		}
+		list := []xiangongyun.Instance{}
+		for i := start; i < end && i < count; i++ {
+			list = append(list, xiangongyun.Instance{ID: fmt.Sprintf("inst-%03d", i), Status: "running"})
+		}
+		data := map[string]interface{}{"list": list}
+		if !ignorePaging {
+			data["total"] = count
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": 200, "success": true, "data": data})
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestListPodsPaging(t *testing.T) {
+	tests := []struct {
+		name         string
+		count        int
+		ignorePaging bool
+		wantRequests int
+	}{
+		{"empty", 0, false, 1},
+		{"short page", 20, false, 1},
+		{"exact pages", 100, false, 2},
+		{"several pages", 120, false, 3},
+		{"paging ignored", 60, true, 2},
+		{"paging ignored, exact page", 50, true, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := instancesServer(t, tt.count, tt.ignorePaging)
+			opts := xiangongyun.DefaultOptions()
+			opts.BaseURL = server.URL
+			opts.RateLimit = 0
+			pool := xiangongyun.NewPoolWithOptions("Bearer test", opts)
+
+			pods, err := pool.ListPods(context.Background(), internal.ListOptions{})
+			if err != nil {
+				t.Fatalf("ListPods: %v", err)
+			}
+			if len(pods) != tt.count {
+				t.Errorf("ListPods returned %d pods, want %d", len(pods), tt.count)
+			}
+			seen := make(map[string]bool)
+			for _, pod := range pods {
+				if seen[pod.ID] {
+					t.Errorf("pod %s listed twice", pod.ID)
+				}
+				seen[pod.ID] = true
+			}
+			if *requests != tt.wantRequests {
+				t.Errorf("sent %d requests, want %d", *requests, tt.wantRequests)
+			}
+		})
+	}
+}
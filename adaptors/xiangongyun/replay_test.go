@@ -0,0 +1,75 @@
+package xiangongyun_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/funstory-ai/gobun/adaptors/xiangongyun"
+	"github.com/funstory-ai/gobun/internal"
+	"github.com/funstory-ai/gobun/internal/utils/cassette"
+)
+
+// newReplayPool creates the pool the way XGY_REPLAY does, without a token
+func newReplayPool(t *testing.T, file string) internal.Pool {
+	t.Helper()
+	t.Setenv(xiangongyun.EnvToken, "")
+	t.Setenv(xiangongyun.EnvRecord, "")
+	t.Setenv(xiangongyun.EnvReplay, file)
+	pool, err := internal.NewPool(xiangongyun.PoolID)
+	if err != nil {
+		t.Fatalf("NewPool: %v", err)
+	}
+	return pool
+}
+
+func TestReplayInstances(t *testing.T) {
+	ctx := context.Background()
+	pool := newReplayPool(t, "testdata/instances.json")
+
+	pods, err := pool.ListPods(ctx, internal.ListOptions{})
+	if err != nil {
+		t.Fatalf("ListPods: %v", err)
+	}
+	if len(pods) != 2 {
+		t.Fatalf("ListPods returned %d pods, want 2", len(pods))
+	}
+	train := pods[0]
+	if train.ID != "inst-a1b2" || train.Name != "train" || train.Status != internal.StatusRunning {
+		t.Errorf("first pod = %s %s %s", train.ID, train.Name, train.Status)
+	}
+	if train.GPUModel != internal.GPUModelRTX4090 || train.GPUCount != 2 {
+		t.Errorf("first pod has %d x %s, want 2 x %s", train.GPUCount, train.GPUModel, internal.GPUModelRTX4090)
+	}
+	if train.Password != cassette.Redacted || train.JupyterToken != cassette.Redacted {
+		t.Errorf("fixture carries credentials: password %q, jupyter token %q", train.Password, train.JupyterToken)
+	}
+
+	pod, err := pool.GetPod(ctx, "inst-c3d4")
+	if err != nil {
+		t.Fatalf("GetPod: %v", err)
+	}
+	if pod.Status != internal.StatusCreating || pod.Progress != 45 || pod.GPUModel != internal.GPUModelRTX4090_D {
+		t.Errorf("GetPod = %s %d%% %s", pod.Status, pod.Progress, pod.GPUModel)
+	}
+	if _, err := pool.GetPod(ctx, "inst-missing"); !errors.Is(err, internal.ErrPodNotFound) {
+		t.Errorf("GetPod of a missing instance = %v, want %v", err, internal.ErrPodNotFound)
+	}
+
+	offers, err := pool.ListOffers(ctx)
+	if err != nil {
+		t.Fatalf("ListOffers: %v", err)
+	}
+	if len(offers) != 2 || offers[0].GPUModel != internal.GPUModelRTX4090 || offers[0].DataCenterID != "1" {
+		t.Errorf("ListOffers = %+v", offers)
+	}
+	// GPUs missing from the catalog keep the provider name
+	if offers[1].GPUModel != "NVIDIA H20" {
+		t.Errorf("unknown GPU model = %q, want the provider name", offers[1].GPUModel)
+	}
+
+	// Every interaction was used, anything else is not in the cassette
+	if _, err := pool.ListOffers(ctx); err == nil {
+		t.Error("a call missing from the cassette succeeded")
+	}
+}
@@ -0,0 +1,134 @@
+package xiangongyun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/funstory-ai/gobun/internal"
+)
+
+func TestDoStopsWhenContextEndsDuringBackoff(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	api := NewAPI("token", Options{
+		BaseURL: server.URL,
+		Retry:   RetryPolicy{MaxAttempts: 3, BaseDelay: time.Hour, MaxDelay: time.Hour},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := api.DoRequest(ctx, http.MethodGet, "/open/instances", nil, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("DoRequest = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if requests != 1 {
+		t.Errorf("%d requests were sent, want 1", requests)
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	dialErr := fmt.Errorf("%w: %w", internal.ErrTransient, &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED})
+	readErr := fmt.Errorf("%w: %w", internal.ErrTransient, &net.OpError{Op: "read", Err: syscall.ECONNRESET})
+	tests := []struct {
+		name       string
+		err        error
+		idempotent bool
+		want       bool
+	}{
+		{"dial", dialErr, false, true},
+		{"rate limited", fmt.Errorf("%w: too many requests", internal.ErrRateLimited), false, true},
+		{"read after send", readErr, false, false},
+		{"read after send, idempotent", readErr, true, true},
+		{"server error", fmt.Errorf("%w: http status 502", internal.ErrTransient), false, false},
+		{"server error, idempotent", fmt.Errorf("%w: http status 502", internal.ErrTransient), true, true},
+		{"unexpected EOF, idempotent", io.ErrUnexpectedEOF, true, true},
+		{"cancelled", fmt.Errorf("%w: %w", internal.ErrTransient, context.Canceled), true, false},
+		{"deadline", context.DeadlineExceeded, true, false},
+		{"unauthorized", internal.ErrUnauthorized, true, false},
+		{"out of stock", internal.ErrOutOfStock, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryable(tt.err, tt.idempotent); got != tt.want {
+				t.Errorf("retryable(%v, %v) = %v, want %v", tt.err, tt.idempotent, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	tests := []struct {
+		retry int
+		full  time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{9, time.Second},
+	}
+	for _, tt := range tests {
+		// the jitter takes off up to half of the delay
+		for i := 0; i < 20; i++ {
+			if got := policy.backoff(tt.retry); got < tt.full/2 || got > tt.full {
+				t.Errorf("backoff(%d) = %s, want between %s and %s", tt.retry, got, tt.full/2, tt.full)
+			}
+		}
+	}
+	if got := (RetryPolicy{}).backoff(1); got != 0 {
+		t.Errorf("backoff without delay = %s, want 0", got)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	if newRateLimiter(0, 5) != nil {
+		t.Error("a rate of 0 does not disable limiting")
+	}
+	var disabled *rateLimiter
+	if err := disabled.wait(context.Background()); err != nil {
+		t.Errorf("wait of a disabled limiter = %v", err)
+	}
+
+	// 20 calls per second in bursts of 3: the burst passes at once, the
+	// next call waits for a token
+	limiter := newRateLimiter(20, 3)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limiter.wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 30*time.Millisecond {
+		t.Errorf("a burst of 3 took %s", elapsed)
+	}
+	if err := limiter.wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("the call after the burst passed after %s, want about 50ms", elapsed)
+	}
+
+	// a cancelled wait gives its token back
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := limiter.wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("wait with a cancelled context = %v, want %v", err, context.Canceled)
+	}
+	if limiter.tokens < -0.5 {
+		t.Errorf("cancelled wait kept its token, %.2f tokens left", limiter.tokens)
+	}
+}
@@ -0,0 +1,57 @@
+package xiangongyun
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/funstory-ai/gobun/internal"
+)
+
+// autoShutdownActions are the XianGongYun codes of the auto-shutdown actions
+var autoShutdownActions = map[internal.AutoShutdownAction]int{
+	internal.AutoShutdownStop:    0,
+	internal.AutoShutdownDestroy: 1,
+}
+
+// SetAutoShutdown sets the auto-shutdown time of an instance, the call is
+// idempotent since it sends an absolute time
+func (p *Pool) SetAutoShutdown(ctx context.Context, podID string, after time.Duration, action internal.AutoShutdownAction) error {
+	payload, err := autoShutdownPayload(time.Now(), after, action)
+	if err != nil {
+		return err
+	}
+	payload["id"] = podID
+	if err := p.api.DoIdempotentRequest(ctx, "POST", "/open/instance/auto_shutdown", payload, nil); err != nil {
+		return fmt.Errorf("failed to set auto-shutdown of pod %s: %w", podID, err)
+	}
+	return nil
+}
+
+// autoShutdownPayload returns the auto-shutdown fields of a request
+func autoShutdownPayload(now time.Time, after time.Duration, action internal.AutoShutdownAction) (map[string]interface{}, error) {
+	if after <= 0 {
+		return map[string]interface{}{"auto_shutdown": 0, "auto_shutdown_action": 0}, nil
+	}
+	if action == "" {
+		action = internal.AutoShutdownStop
+	}
+	code, ok := autoShutdownActions[action]
+	if !ok {
+		return nil, fmt.Errorf("unsupported auto-shutdown action: %s", action)
+	}
+	return map[string]interface{}{
+		"auto_shutdown":        now.Add(after).Unix(),
+		"auto_shutdown_action": code,
+	}, nil
+}
+
+// autoShutdownAction returns the action of a XianGongYun code
+func autoShutdownAction(code int) internal.AutoShutdownAction {
+	for action, c := range autoShutdownActions {
+		if c == code {
+			return action
+		}
+	}
+	return internal.AutoShutdownAction(fmt.Sprint(code))
+}
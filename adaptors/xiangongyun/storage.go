@@ -0,0 +1,39 @@
+package xiangongyun
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/funstory-ai/gobun/adaptors/xiangongyun/xgcos"
+	"github.com/funstory-ai/gobun/internal"
+	"github.com/funstory-ai/gobun/internal/utils/fileutil"
+)
+
+const (
+	// EnvStorageURL and EnvStorageToken set the XGCOS credentials directly,
+	// otherwise they are taken from one of the instances
+	EnvStorageURL   = "XGCOS_URL"
+	EnvStorageToken = "XGCOS_TOKEN"
+)
+
+// Storage returns the XGCOS storage of the account
+func (p *Pool) Storage(ctx context.Context) (internal.Storage, error) {
+	stateDir := filepath.Join(fileutil.DefaultCacheDir, "xgcos-uploads")
+	baseURL, token := os.Getenv(EnvStorageURL), os.Getenv(EnvStorageToken)
+	if baseURL != "" && token != "" {
+		return xgcos.NewClient(baseURL, token, stateDir), nil
+	}
+
+	instances, err := p.listInstances(ctx, internal.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find storage credentials: %w", err)
+	}
+	for _, instance := range instances {
+		if instance.XGCOSURL != "" && instance.XGCOSToken != "" {
+			return xgcos.NewClient(instance.XGCOSURL, instance.XGCOSToken, stateDir), nil
+		}
+	}
+	return nil, fmt.Errorf("no instance carries storage credentials, create one or set %s and %s", EnvStorageURL, EnvStorageToken)
+}
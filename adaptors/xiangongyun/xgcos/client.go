@@ -0,0 +1,191 @@
+// Package xgcos is a client of XGCOS, the XianGongYun object storage. Every
+// instance of an account mounts the same storage, and the instance details
+// carry its URL and an access token, which also work from outside of the
+// instances. Uploads and downloads are resumable.
+package xgcos
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/funstory-ai/gobun/internal"
+)
+
+// DefaultPartSize is the size of the parts of a multipart upload
+const DefaultPartSize = 16 << 20
+
+// ObjectInfo is an object as returned by XGCOS
+type ObjectInfo struct {
+	Key          string `json:"key"`
+	Size         int64  `json:"size"`
+	LastModified int64  `json:"last_modified"`
+	ETag         string `json:"etag"`
+}
+
+// Part is an uploaded part of a multipart upload
+type Part struct {
+	PartNumber int    `json:"part_number"`
+	ETag       string `json:"etag"`
+	Size       int64  `json:"size"`
+}
+
+type Client struct {
+	baseURL string
+	token   string
+	client  *http.Client
+
+	// PartSize is the size of the upload parts, changing it restarts
+	// interrupted uploads
+	PartSize int64
+	// StateDir keeps the state of interrupted transfers
+	StateDir string
+}
+
+// NewClient creates a client, uploads keep their resume state in stateDir
+func NewClient(baseURL string, token string, stateDir string) *Client {
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		// No timeout, transfers of large objects take long, callers bound
+		// them with the context
+		client:   &http.Client{},
+		PartSize: DefaultPartSize,
+		StateDir: stateDir,
+	}
+}
+
+// List returns the objects whose key starts with prefix, sorted by key
+func (c *Client) List(ctx context.Context, prefix string) ([]internal.Object, error) {
+	var objects []internal.Object
+	marker := ""
+	for {
+		query := url.Values{}
+		query.Set("prefix", prefix)
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+		var page struct {
+			Objects    []ObjectInfo `json:"objects"`
+			NextMarker string       `json:"next_marker"`
+		}
+		if err := c.doJSON(ctx, http.MethodGet, "/objects?"+query.Encode(), nil, &page); err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", err)
+		}
+		for _, info := range page.Objects {
+			objects = append(objects, internal.Object{
+				Key:          info.Key,
+				Size:         info.Size,
+				LastModified: info.LastModified,
+			})
+		}
+		if page.NextMarker == "" {
+			return objects, nil
+		}
+		marker = page.NextMarker
+	}
+}
+
+// Stat returns an object
+func (c *Client) Stat(ctx context.Context, key string) (ObjectInfo, error) {
+	resp, err := c.do(ctx, http.MethodHead, objectPath(key), nil, nil)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	resp.Body.Close()
+	lastModified, _ := strconv.ParseInt(resp.Header.Get("X-Last-Modified"), 10, 64)
+	return ObjectInfo{
+		Key:          key,
+		Size:         resp.ContentLength,
+		LastModified: lastModified,
+		ETag:         resp.Header.Get("ETag"),
+	}, nil
+}
+
+func (c *Client) Remove(ctx context.Context, key string) error {
+	resp, err := c.do(ctx, http.MethodDelete, objectPath(key), nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to remove %s: %w", key, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// objectPath returns the URL path of an object, escaping each segment of the key
+func objectPath(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return "/objects/" + strings.Join(segments, "/")
+}
+
+// doJSON sends a JSON body and decodes a JSON response into out
+func (c *Client) doJSON(ctx context.Context, method string, path string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	resp, err := c.do(ctx, method, path, reader, http.Header{"Content-Type": {"application/json"}})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%s %s: decoding response failed: %w", method, path, err)
+	}
+	return nil
+}
+
+// do sends a request and returns the response if its status is 2xx
+func (c *Client) do(ctx context.Context, method string, path string, body io.Reader, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if length := req.Header.Get("Content-Length"); length != "" {
+		// Bodies of unknown type are sent chunked unless the length is set
+		req.ContentLength, _ = strconv.ParseInt(length, 10, 64)
+		req.Header.Del("Content-Length")
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %w", internal.ErrTransient, err)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf("%s %s: http status %d %s", method, path, resp.StatusCode, strings.TrimSpace(string(message)))
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return nil, fmt.Errorf("%w: %w", internal.ErrUnauthorized, err)
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return nil, fmt.Errorf("%w: %w", internal.ErrRateLimited, err)
+	case resp.StatusCode >= 500:
+		return nil, fmt.Errorf("%w: %w", internal.ErrTransient, err)
+	default:
+		return nil, err
+	}
+}
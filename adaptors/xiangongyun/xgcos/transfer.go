@@ -0,0 +1,289 @@
+package xgcos
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/funstory-ai/gobun/internal"
+)
+
+// partialSuffix is appended to the local path of an unfinished download
+const partialSuffix = ".gobun-part"
+
+// uploadState is what is needed to resume an upload, it is only valid for
+// the same file content, approximated by size and modification time
+type uploadState struct {
+	Key      string `json:"key"`
+	UploadID string `json:"upload_id"`
+	Size     int64  `json:"size"`
+	ModTime  int64  `json:"mod_time"`
+	PartSize int64  `json:"part_size"`
+}
+
+// downloadState is the version of the object a partial download holds
+type downloadState struct {
+	Key  string `json:"key"`
+	ETag string `json:"etag"`
+}
+
+// Upload copies a local file to key with a multipart upload. The upload ID
+// is kept in StateDir until the upload completes, so that running Upload
+// again with the same file and key only sends the missing parts.
+func (c *Client) Upload(ctx context.Context, localPath string, key string, progress internal.Progress) error {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if stat.IsDir() {
+		return fmt.Errorf("%s is a directory", localPath)
+	}
+
+	want := uploadState{
+		Key:      key,
+		Size:     stat.Size(),
+		ModTime:  stat.ModTime().UnixNano(),
+		PartSize: c.PartSize,
+	}
+	statePath, err := c.statePath("upload", localPath, key)
+	if err != nil {
+		return err
+	}
+	uploaded := make(map[int]Part)
+	var state uploadState
+	if loadState(statePath, &state) && state.Key == want.Key && state.Size == want.Size && state.ModTime == want.ModTime && state.PartSize == want.PartSize {
+		parts, err := c.listParts(ctx, key, state.UploadID)
+		if err == nil {
+			for _, part := range parts {
+				uploaded[part.PartNumber] = part
+			}
+			want.UploadID = state.UploadID
+		}
+	}
+	if want.UploadID == "" {
+		if want.UploadID, err = c.initiateUpload(ctx, key); err != nil {
+			return fmt.Errorf("failed to upload %s: %w", localPath, err)
+		}
+		if err := saveState(statePath, want); err != nil {
+			return err
+		}
+	}
+
+	partCount := int((want.Size + c.PartSize - 1) / c.PartSize)
+	if partCount == 0 {
+		partCount = 1
+	}
+	parts := make([]Part, 0, partCount)
+	var done int64
+	for number := 1; number <= partCount; number++ {
+		offset := int64(number-1) * c.PartSize
+		size := min(c.PartSize, want.Size-offset)
+		part, ok := uploaded[number]
+		if !ok || part.Size != size {
+			section := io.NewSectionReader(file, offset, size)
+			if part, err = c.uploadPart(ctx, key, want.UploadID, number, section, size); err != nil {
+				return fmt.Errorf("failed to upload %s: %w", localPath, err)
+			}
+		}
+		parts = append(parts, part)
+		done += size
+		if progress != nil {
+			progress(done, want.Size)
+		}
+	}
+
+	if err := c.completeUpload(ctx, key, want.UploadID, parts); err != nil {
+		return fmt.Errorf("failed to upload %s: %w", localPath, err)
+	}
+	os.Remove(statePath)
+	return nil
+}
+
+// Download copies key to a local file. The data is written to a partial
+// file next to it first, running Download again continues that file as
+// long as the object did not change.
+func (c *Client) Download(ctx context.Context, key string, localPath string, progress internal.Progress) error {
+	info, err := c.Stat(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	statePath, err := c.statePath("download", localPath, key)
+	if err != nil {
+		return err
+	}
+	var state downloadState
+	if !loadState(statePath, &state) || state.Key != key || state.ETag != info.ETag {
+		// The partial file, if any, holds another version of the object
+		state = downloadState{Key: key, ETag: info.ETag}
+		if err := saveState(statePath, state); err != nil {
+			return err
+		}
+		os.Remove(localPath + partialSuffix)
+	}
+	partialPath := localPath + partialSuffix
+	file, err := os.OpenFile(partialPath, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	offset, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	// Size is -1 when the HEAD response has no Content-Length, the data is
+	// then fetched in any case and the GET response tells the size
+	size := info.Size
+	if size >= 0 && offset > size {
+		offset = 0
+	}
+
+	if size < 0 || offset < size || size == 0 {
+		header := http.Header{}
+		if offset > 0 {
+			header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+			header.Set("If-Range", info.ETag)
+		}
+		resp, err := c.do(ctx, http.MethodGet, objectPath(key), nil, header)
+		if err != nil {
+			return fmt.Errorf("failed to download %s: %w", key, err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusPartialContent {
+			// The object changed or the range was ignored, start over
+			offset = 0
+		}
+		if resp.ContentLength >= 0 {
+			size = offset + resp.ContentLength
+		}
+		if err := file.Truncate(offset); err != nil {
+			return err
+		}
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+		writer := &progressWriter{w: file, done: offset, total: size, progress: progress}
+		if _, err := io.Copy(writer, resp.Body); err != nil {
+			return fmt.Errorf("failed to download %s: %w", key, err)
+		}
+		if size >= 0 && writer.done != size {
+			return fmt.Errorf("failed to download %s: got %d of %d bytes", key, writer.done, size)
+		}
+		if size < 0 && progress != nil {
+			progress(writer.done, writer.done)
+		}
+	} else if progress != nil {
+		progress(size, size)
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(partialPath, localPath); err != nil {
+		return err
+	}
+	os.Remove(statePath)
+	return nil
+}
+
+func (c *Client) initiateUpload(ctx context.Context, key string) (string, error) {
+	var data struct {
+		UploadID string `json:"upload_id"`
+	}
+	if err := c.doJSON(ctx, http.MethodPost, objectPath(key)+"?uploads", nil, &data); err != nil {
+		return "", err
+	}
+	if data.UploadID == "" {
+		return "", errors.New("no upload id in the response")
+	}
+	return data.UploadID, nil
+}
+
+func (c *Client) listParts(ctx context.Context, key string, uploadID string) ([]Part, error) {
+	var data struct {
+		Parts []Part `json:"parts"`
+	}
+	query := url.Values{"upload_id": {uploadID}}
+	if err := c.doJSON(ctx, http.MethodGet, objectPath(key)+"?"+query.Encode(), nil, &data); err != nil {
+		return nil, err
+	}
+	return data.Parts, nil
+}
+
+func (c *Client) uploadPart(ctx context.Context, key string, uploadID string, number int, body io.Reader, size int64) (Part, error) {
+	query := url.Values{"upload_id": {uploadID}, "part_number": {strconv.Itoa(number)}}
+	header := http.Header{"Content-Length": {strconv.FormatInt(size, 10)}}
+	resp, err := c.do(ctx, http.MethodPut, objectPath(key)+"?"+query.Encode(), io.NopCloser(body), header)
+	if err != nil {
+		return Part{}, err
+	}
+	resp.Body.Close()
+	return Part{PartNumber: number, ETag: resp.Header.Get("ETag"), Size: size}, nil
+}
+
+func (c *Client) completeUpload(ctx context.Context, key string, uploadID string, parts []Part) error {
+	query := url.Values{"upload_id": {uploadID}}
+	body := map[string]interface{}{"parts": parts}
+	return c.doJSON(ctx, http.MethodPost, objectPath(key)+"?"+query.Encode(), body, nil)
+}
+
+// statePath returns the state file of an upload or download between a local
+// file and key
+func (c *Client) statePath(direction string, localPath string, key string) (string, error) {
+	abs, err := filepath.Abs(localPath)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(direction + "\x00" + c.baseURL + "\x00" + abs + "\x00" + key))
+	return filepath.Join(c.StateDir, hex.EncodeToString(sum[:16])+".json"), nil
+}
+
+// loadState reads a state file into state and reports whether it succeeded
+func loadState(path string, state interface{}) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, state) == nil
+}
+
+func saveState(path string, state interface{}) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// progressWriter reports the bytes written through it
+type progressWriter struct {
+	w        io.Writer
+	done     int64
+	total    int64
+	progress internal.Progress
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.done += int64(n)
+	if w.progress != nil {
+		w.progress(w.done, w.total)
+	}
+	return n, err
+}
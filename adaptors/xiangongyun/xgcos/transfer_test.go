@@ -0,0 +1,130 @@
+package xgcos_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/funstory-ai/gobun/adaptors/xiangongyun/xgcos"
+	"github.com/funstory-ai/gobun/adaptors/xiangongyun/xgcos/xgcostest"
+	"github.com/funstory-ai/gobun/internal"
+)
+
+// testData returns size bytes that differ between parts
+func testData(size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i * 7 / 3)
+	}
+	return data
+}
+
+func newTestClient(t *testing.T) (*xgcos.Client, *xgcostest.Server) {
+	t.Helper()
+	server := xgcostest.NewServer()
+	t.Cleanup(server.Close)
+	client := xgcos.NewClient(server.URL, xgcostest.Token, t.TempDir())
+	client.PartSize = 1 << 10
+	return client, server
+}
+
+func TestUploadResumes(t *testing.T) {
+	ctx := context.Background()
+	client, server := newTestClient(t)
+	data := testData(5<<10 + 100)
+	localPath := filepath.Join(t.TempDir(), "data.bin")
+	if err := os.WriteFile(localPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	server.FailPart(3)
+	if err := client.Upload(ctx, localPath, "datasets/data.bin", nil); !errors.Is(err, internal.ErrTransient) {
+		t.Fatalf("Upload with a failing part = %v, want %v", err, internal.ErrTransient)
+	}
+	if _, ok := server.Get("datasets/data.bin"); ok {
+		t.Fatal("an interrupted upload created the object")
+	}
+	if got := server.PartUploads(); got != 2 {
+		t.Fatalf("%d parts were uploaded before the failure, want 2", got)
+	}
+
+	if err := client.Upload(ctx, localPath, "datasets/data.bin", nil); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	// parts 1 and 2 are not sent again
+	if got := server.PartUploads(); got != 6 {
+		t.Errorf("%d parts were uploaded in total, want 6", got)
+	}
+	if got, _ := server.Get("datasets/data.bin"); !bytes.Equal(got, data) {
+		t.Errorf("uploaded object has %d bytes, want the %d bytes of the file", len(got), len(data))
+	}
+}
+
+func TestDownloadResumes(t *testing.T) {
+	for _, hideSizes := range []bool{false, true} {
+		name := "with size"
+		if hideSizes {
+			name = "without size"
+		}
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			client, server := newTestClient(t)
+			if hideSizes {
+				server.HideSizes()
+			}
+			data := testData(1 << 20)
+			server.Put("checkpoints/last.pt", data)
+			localPath := filepath.Join(t.TempDir(), "last.pt")
+
+			server.CutDownload(600 << 10)
+			if err := client.Download(ctx, "checkpoints/last.pt", localPath, nil); err == nil {
+				t.Fatal("an interrupted download succeeded")
+			}
+			if _, err := os.Stat(localPath); !os.IsNotExist(err) {
+				t.Fatalf("an interrupted download left %s: %v", localPath, err)
+			}
+
+			// the download continues after the bytes that arrived
+			first := int64(-1)
+			var total int64
+			progress := func(done int64, size int64) {
+				if first < 0 {
+					first = done
+				}
+				total = size
+			}
+			if err := client.Download(ctx, "checkpoints/last.pt", localPath, progress); err != nil {
+				t.Fatalf("Download: %v", err)
+			}
+			if first <= 600<<10 {
+				t.Errorf("resumed download started at byte %d, want after %d", first, 600<<10)
+			}
+			if total != int64(len(data)) {
+				t.Errorf("progress total = %d, want %d", total, len(data))
+			}
+			got, err := os.ReadFile(localPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Errorf("downloaded %d bytes, want the %d bytes of the object", len(got), len(data))
+			}
+		})
+	}
+}
+
+func TestDownloadEmptyObject(t *testing.T) {
+	client, server := newTestClient(t)
+	server.HideSizes()
+	server.Put("empty", nil)
+	localPath := filepath.Join(t.TempDir(), "empty")
+	if err := client.Download(context.Background(), "empty", localPath, nil); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if info, err := os.Stat(localPath); err != nil || info.Size() != 0 {
+		t.Errorf("downloaded empty object: %v", err)
+	}
+}
@@ -0,0 +1,305 @@
+// Package xgcostest provides an in-memory fake of XGCOS, so that transfers
+// can be exercised, including interrupted ones, without network access.
+package xgcostest
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/funstory-ai/gobun/adaptors/xiangongyun/xgcos"
+)
+
+// Token is the only access token accepted by the fake server
+const Token = "fake-xgcos-token"
+
+// pageSize is the number of objects per list page, small to exercise paging
+const pageSize = 100
+
+type object struct {
+	data         []byte
+	etag         string
+	lastModified int64
+}
+
+type upload struct {
+	key   string
+	parts map[int][]byte
+}
+
+// Server is a fake XGCOS server
+type Server struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	objects map[string]*object
+	uploads map[string]*upload
+	nextID  int
+	// failParts are part numbers whose next upload fails
+	failParts map[int]bool
+	// partUploads counts the parts received
+	partUploads int
+	// cutAfter, if positive, is where the next download stops
+	cutAfter int
+	// hideSizes drops Content-Length from HEAD responses
+	hideSizes bool
+}
+
+// NewServer starts an empty fake server, the caller must Close it
+func NewServer() *Server {
+	s := &Server{
+		objects:   make(map[string]*object),
+		uploads:   make(map[string]*upload),
+		failParts: make(map[int]bool),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Put stores an object
+func (s *Server) Put(key string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.put(key, data)
+}
+
+// Get returns the data of an object
+func (s *Server) Get(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj, ok := s.objects[key]
+	if !ok {
+		return nil, false
+	}
+	return obj.data, true
+}
+
+// FailPart makes the next upload of the part with the given number fail
+// with a server error, to interrupt an upload
+func (s *Server) FailPart(number int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failParts[number] = true
+}
+
+// PartUploads returns the number of parts received so far
+func (s *Server) PartUploads() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.partUploads
+}
+
+// CutDownload makes the next download stop after the given number of
+// bytes of the body, to interrupt a download
+func (s *Server) CutDownload(bytes int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cutAfter = bytes
+}
+
+// HideSizes makes HEAD responses omit Content-Length, as some gateways do
+func (s *Server) HideSizes() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hideSizes = true
+}
+
+func (s *Server) put(key string, data []byte) {
+	s.objects[key] = &object{
+		data:         data,
+		etag:         partETag(data),
+		lastModified: time.Now().Unix(),
+	}
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+Token {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.URL.Path == "/objects" && r.Method == http.MethodGet {
+		s.list(w, r)
+		return
+	}
+	key, ok := strings.CutPrefix(r.URL.Path, "/objects/")
+	if !ok || key == "" {
+		http.Error(w, "no such endpoint", http.StatusNotFound)
+		return
+	}
+	query := r.URL.Query()
+	_, initiate := query["uploads"]
+	uploadID := query.Get("upload_id")
+	switch {
+	case r.Method == http.MethodPost && initiate:
+		s.nextID++
+		id := fmt.Sprintf("upload-%d", s.nextID)
+		s.uploads[id] = &upload{key: key, parts: make(map[int][]byte)}
+		writeJSON(w, map[string]string{"upload_id": id})
+	case r.Method == http.MethodPut && uploadID != "":
+		s.uploadPart(w, r, key, uploadID)
+	case r.Method == http.MethodGet && uploadID != "":
+		s.listParts(w, key, uploadID)
+	case r.Method == http.MethodPost && uploadID != "":
+		s.completeUpload(w, r, key, uploadID)
+	case r.Method == http.MethodHead || r.Method == http.MethodGet:
+		s.get(w, r, key)
+	case r.Method == http.MethodDelete:
+		if _, ok := s.objects[key]; !ok {
+			http.Error(w, "object not found", http.StatusNotFound)
+			return
+		}
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "no such endpoint", http.StatusNotFound)
+	}
+}
+
+func (s *Server) list(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	marker := r.URL.Query().Get("marker")
+	keys := make([]string, 0, len(s.objects))
+	for key := range s.objects {
+		if strings.HasPrefix(key, prefix) && key > marker {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	nextMarker := ""
+	if len(keys) > pageSize {
+		keys = keys[:pageSize]
+		nextMarker = keys[pageSize-1]
+	}
+	objects := make([]xgcos.ObjectInfo, len(keys))
+	for i, key := range keys {
+		obj := s.objects[key]
+		objects[i] = xgcos.ObjectInfo{Key: key, Size: int64(len(obj.data)), LastModified: obj.lastModified, ETag: obj.etag}
+	}
+	writeJSON(w, map[string]interface{}{"objects": objects, "next_marker": nextMarker})
+}
+
+func (s *Server) get(w http.ResponseWriter, r *http.Request, key string) {
+	obj, ok := s.objects[key]
+	if !ok {
+		http.Error(w, "object not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("ETag", obj.etag)
+	w.Header().Set("X-Last-Modified", strconv.FormatInt(obj.lastModified, 10))
+	data := obj.data
+	status := http.StatusOK
+	if rng := r.Header.Get("Range"); rng != "" && r.Header.Get("If-Range") == obj.etag {
+		var start int
+		if _, err := fmt.Sscanf(rng, "bytes=%d-", &start); err == nil && start <= len(data) {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(data)-1, len(data)))
+			data = data[start:]
+			status = http.StatusPartialContent
+		}
+	}
+	if r.Method == http.MethodHead && s.hideSizes {
+		w.WriteHeader(status)
+		return
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(status)
+	if r.Method != http.MethodGet {
+		return
+	}
+	if s.cutAfter > 0 && s.cutAfter < len(data) {
+		// Returning short of Content-Length drops the connection
+		data = data[:s.cutAfter]
+		s.cutAfter = 0
+	}
+	w.Write(data)
+}
+
+func (s *Server) uploadPart(w http.ResponseWriter, r *http.Request, key string, uploadID string) {
+	u, ok := s.uploads[uploadID]
+	if !ok || u.key != key {
+		http.Error(w, "upload not found", http.StatusNotFound)
+		return
+	}
+	number, err := strconv.Atoi(r.URL.Query().Get("part_number"))
+	if err != nil || number < 1 {
+		http.Error(w, "invalid part number", http.StatusBadRequest)
+		return
+	}
+	if s.failParts[number] {
+		delete(s.failParts, number)
+		http.Error(w, "simulated failure", http.StatusInternalServerError)
+		return
+	}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	u.parts[number] = data
+	s.partUploads++
+	w.Header().Set("ETag", partETag(data))
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) listParts(w http.ResponseWriter, key string, uploadID string) {
+	u, ok := s.uploads[uploadID]
+	if !ok || u.key != key {
+		http.Error(w, "upload not found", http.StatusNotFound)
+		return
+	}
+	parts := make([]xgcos.Part, 0, len(u.parts))
+	for number, data := range u.parts {
+		parts = append(parts, xgcos.Part{PartNumber: number, ETag: partETag(data), Size: int64(len(data))})
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+	writeJSON(w, map[string]interface{}{"parts": parts})
+}
+
+func (s *Server) completeUpload(w http.ResponseWriter, r *http.Request, key string, uploadID string) {
+	u, ok := s.uploads[uploadID]
+	if !ok || u.key != key {
+		http.Error(w, "upload not found", http.StatusNotFound)
+		return
+	}
+	var body struct {
+		Parts []xgcos.Part `json:"parts"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var data []byte
+	for i, part := range body.Parts {
+		stored, ok := u.parts[part.PartNumber]
+		if !ok || part.PartNumber != i+1 || part.ETag != partETag(stored) {
+			http.Error(w, fmt.Sprintf("invalid part %d", part.PartNumber), http.StatusBadRequest)
+			return
+		}
+		data = append(data, stored...)
+	}
+	delete(s.uploads, uploadID)
+	s.put(key, data)
+	writeJSON(w, map[string]string{"key": key})
+}
+
+func partETag(data []byte) string {
+	sum := md5.Sum(data)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
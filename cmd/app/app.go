@@ -1,12 +1,21 @@
 package app
 
-import "github.com/urfave/cli/v2"
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/funstory-ai/gobun/internal"
+	"github.com/urfave/cli/v2"
+)
 
 type BunApp struct {
 	cli.App
 }
 
 func New() BunApp {
+	registerRESTPools()
+
 	internalApp := cli.NewApp()
 	internalApp.EnableBashCompletion = true
 	internalApp.Name = "GoBun"
@@ -18,6 +27,31 @@ func New() BunApp {
 			Name:  "debug",
 			Usage: "enable debug output in logs",
 		},
+		&cli.StringFlag{
+			Name:    "pool",
+			Aliases: []string{"p"},
+			EnvVars: []string{"GOBUN_POOL"},
+			Usage: fmt.Sprintf("pool provider to use, one of: %s (defaults to default_pool in the config file)",
+				strings.Join(internal.RegisteredPools(), ", ")),
+		},
+		&cli.DurationFlag{
+			Name:  "timeout",
+			Usage: "abort the command after the given duration, e.g. 30s or 5m, shells and tunnels are not aborted (0 means no timeout)",
+		},
+	}
+	var cancelTimeout context.CancelFunc
+	internalApp.Before = func(ctx *cli.Context) error {
+		if timeout := ctx.Duration("timeout"); timeout > 0 {
+			untimed := context.WithValue(ctx.Context, untimedContextKey{}, ctx.Context)
+			ctx.Context, cancelTimeout = context.WithTimeout(untimed, timeout)
+		}
+		return nil
+	}
+	internalApp.After = func(ctx *cli.Context) error {
+		if cancelTimeout != nil {
+			cancelTimeout()
+		}
+		return nil
 	}
 	internalApp.Commands = []*cli.Command{
 		CommandList,
@@ -25,8 +59,34 @@ func New() BunApp {
 		CommandAttach,
 		CommandDestroy,
 		CommandUp,
+		CommandStop,
+		CommandStart,
+		CommandRestart,
+		CommandOffers,
+		CommandImage,
+		CommandDataCenters,
+		CommandBalance,
+		CommandBilling,
+		CommandAutoShutdown,
+		CommandDescribe,
+		CommandStorage,
+		CommandJupyter,
+		CommandDisk,
 	}
 	return BunApp{
 		App: *internalApp,
 	}
 }
+
+// untimedContextKey keeps the command context from before --timeout
+type untimedContextKey struct{}
+
+// sessionContext returns the command context without the deadline of
+// --timeout, for interactive sessions that last as long as the user wants.
+// It is still cancelled by signals.
+func sessionContext(ctx *cli.Context) context.Context {
+	if untimed, ok := ctx.Context.Value(untimedContextKey{}).(context.Context); ok {
+		return untimed
+	}
+	return ctx.Context
+}
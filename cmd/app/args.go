@@ -0,0 +1,57 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// commandArgs returns the positional arguments of a command and applies the
+// flags given among them. urfave/cli stops parsing flags at the first
+// positional argument, which would ignore -r in
+// "gobun storage cp ./data cos:datasets/ -r".
+func commandArgs(ctx *cli.Context) ([]string, error) {
+	var args []string
+	rest := ctx.Args().Slice()
+	for i := 0; i < len(rest); i++ {
+		arg := rest[i]
+		if arg == "--" {
+			args = append(args, rest[i+1:]...)
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			args = append(args, arg)
+			continue
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		flag := lookupFlag(ctx.Command.Flags, name)
+		if flag == nil {
+			return nil, cli.Exit(fmt.Sprintf("flag provided but not defined: %s", arg), 1)
+		}
+		if _, ok := flag.(*cli.BoolFlag); ok && !hasValue {
+			value = "true"
+		} else if !hasValue {
+			if i+1 == len(rest) {
+				return nil, cli.Exit(fmt.Sprintf("flag needs an argument: %s", arg), 1)
+			}
+			i++
+			value = rest[i]
+		}
+		if err := ctx.Set(flag.Names()[0], value); err != nil {
+			return nil, cli.Exit(fmt.Sprintf("invalid value %q for flag %s: %v", value, arg, err), 1)
+		}
+	}
+	return args, nil
+}
+
+// lookupFlag returns the flag with name among its names, nil if there is none
+func lookupFlag(flags []cli.Flag, name string) cli.Flag {
+	for _, flag := range flags {
+		if slices.Contains(flag.Names(), name) {
+			return flag
+		}
+	}
+	return nil
+}
@@ -1,11 +1,11 @@
 package app
 
 import (
+	"context"
 	"fmt"
-	"os"
 	"strconv"
 
-	"github.com/funstory-ai/gobun/adaptors/xiangongyun"
+	"github.com/funstory-ai/gobun/internal"
 	"github.com/funstory-ai/gobun/internal/ssh"
 	"github.com/urfave/cli/v2"
 )
@@ -22,16 +22,41 @@ func attach(ctx *cli.Context) error {
 		return cli.Exit("Pod ID is required", 1)
 	}
 	id := ctx.Args().First()
-	pool := xiangongyun.NewPool("Bearer " + os.Getenv("XGY_TOKEN"))
-	pod, err := pool.GetPod(id)
+	pool, err := newPool(ctx)
+	if err != nil {
+		return err
+	}
+	pod, err := pool.GetPod(ctx.Context, id)
 	if err != nil {
 		return fmt.Errorf("failed to get pod: %w", err)
 	}
 
-	// Create SSH client options
+	return attachToPod(ctx, pod)
+}
+
+// attachToPod opens an interactive shell on a pod
+func attachToPod(ctx *cli.Context, pod internal.Pod) error {
+	// --timeout bounds getting the pod ready, not the shell
+	sessionCtx := sessionContext(ctx)
+	client, err := newSSHClient(sessionCtx, pod)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	// Attach to the pod
+	if err := client.Attach(sessionCtx); err != nil {
+		return fmt.Errorf("failed to attach to pod: %w", err)
+	}
+
+	return nil
+}
+
+// newSSHClient connects to the SSH endpoint of a pod
+func newSSHClient(ctx context.Context, pod internal.Pod) (ssh.Client, error) {
 	port, err := strconv.Atoi(pod.SSHPort)
 	if err != nil {
-		return fmt.Errorf("failed to parse SSH port: %w", err)
+		return nil, fmt.Errorf("failed to parse SSH port: %w", err)
 	}
 	opt := ssh.Options{
 		Server:   pod.SSHDomain,
@@ -40,18 +65,9 @@ func attach(ctx *cli.Context) error {
 		Password: pod.Password,
 		Auth:     true,
 	}
-
-	// Create new SSH client
-	client, err := ssh.NewClient(opt)
+	client, err := ssh.NewClient(ctx, opt)
 	if err != nil {
-		return fmt.Errorf("failed to create SSH client: %w", err)
+		return nil, fmt.Errorf("failed to create SSH client: %w", err)
 	}
-	defer client.Close()
-
-	// Attach to the pod
-	if err := client.Attach(); err != nil {
-		return fmt.Errorf("failed to attach to pod: %w", err)
-	}
-
-	return nil
+	return client, nil
 }
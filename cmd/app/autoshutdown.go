@@ -0,0 +1,73 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/funstory-ai/gobun/internal"
+	"github.com/urfave/cli/v2"
+)
+
+var CommandAutoShutdown = &cli.Command{
+	Name:      "auto-shutdown",
+	Usage:     "Change when a pod is shut down automatically, off turns it off",
+	ArgsUsage: "<pod-id> <duration|off>",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "action",
+			Usage: "what auto-shutdown does to the pod, stop or destroy",
+			Value: string(internal.AutoShutdownStop),
+		},
+	},
+	Action: autoShutdown,
+}
+
+func autoShutdown(ctx *cli.Context) error {
+	args, err := commandArgs(ctx)
+	if err != nil {
+		return err
+	}
+	if len(args) != 2 {
+		return cli.Exit("Pod ID and duration are required", 1)
+	}
+	podID := args[0]
+	var after time.Duration
+	if arg := args[1]; arg != "off" {
+		d, err := time.ParseDuration(arg)
+		if err != nil || d <= 0 {
+			return cli.Exit(fmt.Sprintf("Invalid duration %q, use e.g. 3h or off", arg), 1)
+		}
+		after = d
+	}
+	action, err := internal.ParseAutoShutdownAction(ctx.String("action"))
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+
+	pool, err := newPool(ctx)
+	if err != nil {
+		return err
+	}
+	shutdownPool, ok := pool.(internal.AutoShutdownPool)
+	if !ok {
+		return cli.Exit(fmt.Sprintf("Pool %s does not support auto-shutdown", pool.ID()), 1)
+	}
+	if err := shutdownPool.SetAutoShutdown(ctx.Context, podID, after, action); err != nil {
+		return err
+	}
+	if after == 0 {
+		fmt.Printf("Auto-shutdown of pod %s is off\n", podID)
+	} else {
+		fmt.Printf("Pod %s will %s in %s\n", podID, action, after)
+	}
+	return nil
+}
+
+// formatAutoShutdown renders the time left until a pod is shut down automatically
+func formatAutoShutdown(pod internal.Pod) string {
+	left, ok := pod.AutoShutdownIn(time.Now())
+	if !ok {
+		return "-"
+	}
+	return fmt.Sprintf("%s in %s", pod.AutoShutdownAction, left.Round(time.Minute))
+}
@@ -0,0 +1,206 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/funstory-ai/gobun/internal"
+	bunconfig "github.com/funstory-ai/gobun/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/term"
+)
+
+var CommandBalance = &cli.Command{
+	Name:   "balance",
+	Usage:  "Show the account balance and how long it lasts at the current spend",
+	Flags:  []cli.Flag{outputFlag},
+	Action: balance,
+}
+
+var CommandBilling = &cli.Command{
+	Name:  "billing",
+	Usage: "Show recent charges",
+	Flags: []cli.Flag{
+		&cli.DurationFlag{
+			Name:  "since",
+			Usage: "show charges of this long ago up to now",
+			Value: 7 * 24 * time.Hour,
+		},
+		&cli.BoolFlag{
+			Name:  "by-pod",
+			Usage: "sum the charges per pod",
+		},
+	},
+	Action: billing,
+}
+
+// newBillingPool returns the selected pool if it reports billing
+func newBillingPool(ctx *cli.Context) (internal.Pool, internal.BillingPool, error) {
+	pool, err := newPool(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	billingPool, ok := pool.(internal.BillingPool)
+	if !ok {
+		return nil, nil, cli.Exit(fmt.Sprintf("Pool %s does not report billing", pool.ID()), 1)
+	}
+	return pool, billingPool, nil
+}
+
+// balanceReport is what balance prints, RunwayHours is nil when nothing is
+// spent
+type balanceReport struct {
+	internal.Balance
+	SpendPerHour float64  `json:"spend_per_hour"`
+	RunwayHours  *float64 `json:"runway_hours"`
+}
+
+func balance(ctx *cli.Context) error {
+	format, err := outputFormatFromFlags(ctx)
+	if err != nil {
+		return err
+	}
+	pool, billingPool, err := newBillingPool(ctx)
+	if err != nil {
+		return err
+	}
+	b, err := billingPool.GetBalance(ctx.Context)
+	if err != nil {
+		return err
+	}
+	spend, err := hourlySpend(ctx, pool, "")
+	if err != nil {
+		return err
+	}
+
+	report := balanceReport{Balance: b, SpendPerHour: spend}
+	if spend > 0 {
+		hours := b.Amount / spend
+		report.RunwayHours = &hours
+	}
+
+	return printOutput(format, report, func(bool) error {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.Debug)
+		fmt.Fprintln(w, "POOL ID\tBALANCE\tSPEND/HOUR\tLASTS")
+		fmt.Fprintf(w, "%s\t%.2f %s\t%.2f\t%s\n", b.PoolID, b.Amount, b.Currency, spend, formatRunway(b.Amount, spend))
+		return w.Flush()
+	})
+}
+
+func billing(ctx *cli.Context) error {
+	_, billingPool, err := newBillingPool(ctx)
+	if err != nil {
+		return err
+	}
+	charges, err := billingPool.ListCharges(ctx.Context, time.Now().Add(-ctx.Duration("since")))
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.Debug)
+	if ctx.Bool("by-pod") {
+		totals := make(map[string]float64)
+		for _, charge := range charges {
+			totals[charge.PodID] += charge.Amount
+		}
+		podIDs := make([]string, 0, len(totals))
+		for podID := range totals {
+			podIDs = append(podIDs, podID)
+		}
+		sort.Slice(podIDs, func(i, j int) bool { return totals[podIDs[i]] > totals[podIDs[j]] })
+		fmt.Fprintln(w, "POD ID\tAMOUNT")
+		for _, podID := range podIDs {
+			fmt.Fprintf(w, "%s\t%.2f\n", podID, totals[podID])
+		}
+		return w.Flush()
+	}
+
+	var total float64
+	fmt.Fprintln(w, "TIME\tPOD ID\tDESCRIPTION\tAMOUNT")
+	for _, charge := range charges {
+		total += charge.Amount
+		fmt.Fprintf(w, "%s\t%s\t%s\t%.2f\n",
+			time.Unix(charge.Timestamp, 0).Format(time.DateTime),
+			charge.PodID,
+			charge.Description,
+			charge.Amount,
+		)
+	}
+	fmt.Fprintf(w, "\t\tTOTAL\t%.2f\n", total)
+	return w.Flush()
+}
+
+// hourlySpend returns the hourly price of the running and creating pods of
+// a pool, excluding the pod with the given ID
+func hourlySpend(ctx *cli.Context, pool internal.Pool, excludeID string) (float64, error) {
+	pods, err := pool.ListPods(ctx.Context, internal.ListOptions{
+		Statuses: []internal.PodStatus{internal.StatusRunning, internal.StatusCreating},
+	})
+	if err != nil {
+		return 0, err
+	}
+	var spend float64
+	for _, pod := range pods {
+		if pod.ID != excludeID {
+			spend += pod.PricePerHour
+		}
+	}
+	return spend, nil
+}
+
+// formatRunway renders how long a balance lasts at an hourly spend
+func formatRunway(balance float64, spend float64) string {
+	if spend <= 0 {
+		return "-"
+	}
+	return time.Duration(balance / spend * float64(time.Hour)).Round(time.Minute).String()
+}
+
+// checkBalance warns when the balance of the candidate's pool runs out
+// within low_balance_hours at the spend of its pods including the quoted
+// price of the new one, and asks whether to go on when stdin is a terminal.
+// Failing to check must not fail the command, only the user's answer does.
+func checkBalance(ctx *cli.Context, candidate internal.Candidate) error {
+	billingPool, ok := candidate.Pool.(internal.BillingPool)
+	if !ok {
+		return nil
+	}
+	cfg, err := bunconfig.Load()
+	if err != nil || cfg.LowBalanceHours <= 0 {
+		return nil
+	}
+	b, err := billingPool.GetBalance(ctx.Context)
+	if err != nil {
+		logrus.Debugf("failed to check the balance: %v", err)
+		return nil
+	}
+	spend, err := hourlySpend(ctx, candidate.Pool, "")
+	if err != nil {
+		logrus.Debugf("failed to check the balance: %v", err)
+		return nil
+	}
+	if candidate.Quoted {
+		spend += candidate.PricePerHour
+	}
+	if spend <= 0 || b.Amount/spend >= cfg.LowBalanceHours {
+		return nil
+	}
+	runway := formatRunway(b.Amount, spend)
+	logrus.Warnf("Balance of pool %s is %.2f %s, it runs out in %s at %.2f/hour",
+		b.PoolID, b.Amount, b.Currency, runway, spend)
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return nil
+	}
+	confirm, err := getConfirmation(messageWriter(ctx), "Create the pod anyway? (y/N): ")
+	if err != nil {
+		return err
+	}
+	if !confirm {
+		return fmt.Errorf("aborted, the balance of pool %s runs out in %s", b.PoolID, runway)
+	}
+	return nil
+}
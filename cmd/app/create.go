@@ -2,45 +2,63 @@ package app
 
 import (
 	"fmt"
-	"os"
-	"text/tabwriter"
 
-	"github.com/funstory-ai/gobun/adaptors/xiangongyun"
 	"github.com/funstory-ai/gobun/internal"
 	"github.com/urfave/cli/v2"
 )
 
 var CommandCreate = &cli.Command{
-	Name:   "create",
-	Usage:  "Create a new pod",
+	Name:  "create",
+	Usage: "Create a new pod on the cheapest pool that has the GPUs",
+	Flags: append([]cli.Flag{
+		&cli.BoolFlag{
+			Name:  "wait",
+			Usage: "wait until the pod is running, showing its progress",
+		},
+		&cli.BoolFlag{
+			Name:  "attach",
+			Usage: "wait until the pod is running and attach to it, the pod is kept when the shell exits",
+		},
+		outputFlag,
+	}, podOptionFlags...),
 	Action: create,
 }
 
 func create(ctx *cli.Context) error {
-	pool := xiangongyun.NewPool("Bearer " + os.Getenv("XGY_TOKEN"))
-
-	// Create pod with default options
-	options := internal.PodOptions{
-		GPUModel: internal.GPUModelRTX4090,
-		GPUCount: 1,
+	format, err := outputFormatFromFlags(ctx)
+	if err != nil {
+		return err
 	}
-	pod, err := pool.CreatePod(options)
+	options, err := podOptionsFromFlags(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to create pod: %w", err)
+		return err
+	}
+	pod, err := placePod(ctx, options)
+	if err != nil {
+		return createPodError(pod, err)
+	}
+	if ctx.Bool("wait") || ctx.Bool("attach") {
+		fmt.Fprintf(messageWriter(ctx), "Pod created successfully (ID: %s)\n", pod.ID)
+		if pod, err = waitForPod(ctx, pod); err != nil {
+			return fmt.Errorf("pod %s is not running, destroy it with gobun destroy if it is not needed: %w", pod.ID, err)
+		}
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.Debug)
-	fmt.Fprintln(w, "ID\tPOOL ID\tNAME\tSTATUS\tGPU\tGPU MODEL\tMEMORY")
-	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
-		pod.ID,
-		pod.PoolID,
-		pod.Name,
-		pod.Status,
-		pod.GPUCount,
-		pod.GPUModel,
-		humanReadableMemory(pod.MemorySize),
-	)
-	w.Flush()
+	err = printOutput(format, pod, func(wide bool) error {
+		return printPods([]internal.Pod{pod}, nil, wide)
+	})
+	if err != nil {
+		return err
+	}
 
-	return nil
+	if !ctx.Bool("attach") {
+		return nil
+	}
+	// Simulated pools have nothing to attach to
+	if pod.SSHDomain == "" {
+		fmt.Fprintln(messageWriter(ctx), "Pod has no SSH endpoint, skipping attach")
+		return nil
+	}
+	fmt.Fprintln(messageWriter(ctx), "Attaching to pod...")
+	return attachToPod(ctx, pod)
 }
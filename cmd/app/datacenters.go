@@ -0,0 +1,65 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/funstory-ai/gobun/internal"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+var CommandDataCenters = &cli.Command{
+	Name:    "datacenters",
+	Aliases: []string{"dc"},
+	Usage:   "List the data centers of the pools and their GPU stock",
+	Action:  dataCenters,
+}
+
+func dataCenters(ctx *cli.Context) error {
+	pools, err := newPools(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.Debug)
+	fmt.Fprintln(w, "POOL ID\tID\tNAME\tREGION\tGPU STOCK")
+	for _, pool := range pools {
+		dcPool, ok := pool.(internal.DataCenterPool)
+		if !ok {
+			if len(pools) == 1 {
+				return cli.Exit(fmt.Sprintf("Pool %s has no data centers", pool.ID()), 1)
+			}
+			continue
+		}
+		dcs, err := dcPool.ListDataCenters(ctx.Context)
+		if err != nil {
+			if len(pools) == 1 {
+				return err
+			}
+			logrus.Warnf("failed to list data centers of pool %s: %v", pool.ID(), err)
+			continue
+		}
+		for _, dc := range dcs {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", dc.PoolID, dc.ID, dc.Name, dc.Region, formatStock(dc.Stock))
+		}
+	}
+	return w.Flush()
+}
+
+// formatStock renders GPU stock as "RTX3090:4 RTX4090:8", sorted by model
+func formatStock(stock map[internal.GPUModel]int) string {
+	models := make([]string, 0, len(stock))
+	for model := range stock {
+		models = append(models, string(model))
+	}
+	sort.Strings(models)
+	parts := make([]string, len(models))
+	for i, model := range models {
+		parts[i] = fmt.Sprintf("%s:%d", model, stock[internal.GPUModel(model)])
+	}
+	return strings.Join(parts, " ")
+}
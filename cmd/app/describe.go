@@ -0,0 +1,102 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/funstory-ai/gobun/internal"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+var CommandDescribe = &cli.Command{
+	Name:      "describe",
+	Usage:     "Show the details of a pod",
+	ArgsUsage: "<pod-id>",
+	Flags:     []cli.Flag{outputFlag},
+	Action:    describe,
+}
+
+func describe(ctx *cli.Context) error {
+	args, err := commandArgs(ctx)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return cli.Exit("Pod ID is required", 1)
+	}
+	format, err := outputFormatFromFlags(ctx)
+	if err != nil {
+		return err
+	}
+	pool, err := newPool(ctx)
+	if err != nil {
+		return err
+	}
+	pod, err := pool.GetPod(ctx.Context, args[0])
+	if err != nil {
+		return err
+	}
+	return printOutput(format, pod, func(bool) error {
+		return describePod(ctx, pod)
+	})
+}
+
+// describePod prints the details of a pod as "Key: value" lines
+func describePod(ctx *cli.Context, pod internal.Pod) error {
+	usage, err := measureDiskUsage(ctx.Context, pod)
+	if err != nil {
+		logrus.Warnf("Failed to measure disk usage: %v", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, field := range [][2]string{
+		{"ID", pod.ID},
+		{"Pool", pod.PoolID},
+		{"Name", pod.Name},
+		{"Status", string(pod.Status)},
+		{"Created", formatCreated(pod)},
+		{"Data center", pod.DataCenterName},
+		{"GPU", fmt.Sprintf("%d x %s", pod.GPUCount, formatGPUModel(pod.GPUModel))},
+		{"CPU", fmt.Sprintf("%d x %s", pod.CPUCoreCount, pod.CPUModel)},
+		{"Memory", humanReadableMemory(pod.MemorySize)},
+		{"System disk", formatDiskUsage(usage.system, pod.SystemDiskSize)},
+		{"Data disk", fmt.Sprintf("%s at %s", formatDiskUsage(usage.data, pod.DataDiskSize), valueOrDash(pod.DataDiskMountPath))},
+		{"Data disk can grow by", humanReadableMemory(pod.ExpandableDataDiskSize)},
+		{"Price/hour", fmt.Sprintf("%.2f", pod.PricePerHour)},
+		{"Image", fmt.Sprintf("%s (%s)", pod.ImageID, pod.ImageType)},
+		{"SSH", formatSSH(pod)},
+		{"Web console", valueOrDash(pod.WebURL)},
+		{"Jupyter", valueOrDash(pod.JupyterURL)},
+		{"Auto-shutdown", formatAutoShutdown(pod)},
+	} {
+		fmt.Fprintf(w, "%s:\t%s\n", field[0], field[1])
+	}
+	return w.Flush()
+}
+
+// formatCreated returns when the pod was created, "-" when unknown
+func formatCreated(pod internal.Pod) string {
+	if pod.CreateTimestamp == 0 {
+		return "-"
+	}
+	return time.Unix(pod.CreateTimestamp, 0).Format(time.DateTime)
+}
+
+// formatSSH returns the SSH endpoint of the pod as user@host -p port
+func formatSSH(pod internal.Pod) string {
+	if pod.SSHDomain == "" {
+		return "-"
+	}
+	return fmt.Sprintf("%s@%s -p %s", pod.SSHUser, pod.SSHDomain, pod.SSHPort)
+}
+
+// valueOrDash returns s, or "-" when it is empty
+func valueOrDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
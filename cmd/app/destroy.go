@@ -3,19 +3,14 @@ package app
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"time"
 
-	"github.com/funstory-ai/gobun/adaptors/xiangongyun"
 	"github.com/urfave/cli/v2"
 )
 
-const (
-	// EnvXGYToken 用于存储环境变量名称
-	EnvXGYToken = "XGY_TOKEN"
-)
-
 // CommandDestroy 定义了 destroy 命令
 var CommandDestroy = &cli.Command{
 	Name:      "destroy",
@@ -25,14 +20,11 @@ var CommandDestroy = &cli.Command{
 }
 
 func destroy(ctx *cli.Context) error {
-	// 获取 XGY_TOKEN 环境变量
-	token := os.Getenv(EnvXGYToken)
-	if token == "" {
-		return fmt.Errorf("环境变量 %s 未设置，请设置后重试", EnvXGYToken)
+	pool, err := newPool(ctx)
+	if err != nil {
+		return err
 	}
 
-	pool := xiangongyun.NewPool("Bearer " + token)
-
 	// 检查是否提供了至少一个 pod ID
 	if ctx.NArg() < 1 {
 		return fmt.Errorf("至少需要一个 pod ID")
@@ -43,7 +35,7 @@ func destroy(ctx *cli.Context) error {
 		fmt.Printf("准备销毁 pod: %s\n", podID)
 
 		// 交互确认
-		confirm, err := getConfirmation(fmt.Sprintf("您确定要销毁 pod %s 吗？(y/N): ", podID))
+		confirm, err := getConfirmation(os.Stdout, fmt.Sprintf("您确定要销毁 pod %s 吗？(y/N): ", podID))
 		if err != nil {
 			fmt.Printf("获取确认失败: %v\n", err)
 			continue
@@ -57,7 +49,7 @@ func destroy(ctx *cli.Context) error {
 		fmt.Printf("正在销毁 pod: %s...\n", podID)
 		statusCh := make(chan string)
 		go func(podID string, statusCh chan<- string) {
-			err := pool.DestroyPod(podID)
+			err := pool.DestroyPod(ctx.Context, podID)
 			if err != nil {
 				statusCh <- fmt.Sprintf("销毁 pod %s 失败: %v", podID, err)
 				return
@@ -77,9 +69,9 @@ func destroy(ctx *cli.Context) error {
 }
 
 // getConfirmation 提示用户确认操作
-func getConfirmation(message string) (bool, error) {
+func getConfirmation(out io.Writer, message string) (bool, error) {
 	reader := bufio.NewReader(os.Stdin)
-	fmt.Print(message)
+	fmt.Fprint(out, message)
 	response, err := reader.ReadString('\n')
 	if err != nil {
 		return false, err
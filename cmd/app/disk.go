@@ -0,0 +1,246 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/funstory-ai/gobun/internal"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+// diskUsageTimeout bounds measuring the disk usage of a pod over SSH
+const diskUsageTimeout = 10 * time.Second
+
+// diskUsageSessions bounds the SSH sessions list opens at once to measure
+// disk usage
+const diskUsageSessions = 4
+
+// diskUsageTTL is how long list --watch shows a measured disk usage before
+// measuring it again
+const diskUsageTTL = time.Minute
+
+var CommandDisk = &cli.Command{
+	Name:  "disk",
+	Usage: "Manage the data disks of pods",
+	Subcommands: []*cli.Command{
+		{
+			Name:      "expand",
+			Usage:     "Grow the data disk of a pod",
+			ArgsUsage: "<pod-id>",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "size",
+					Usage: "new size of the data disk, e.g. 200G, or +50G to add to the current size",
+				},
+			},
+			Action: diskExpand,
+		},
+	},
+}
+
+// newDiskPool returns the selected pool if it supports expanding data disks
+func newDiskPool(ctx *cli.Context) (internal.Pool, internal.DiskPool, error) {
+	pool, err := newPool(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	diskPool, ok := pool.(internal.DiskPool)
+	if !ok {
+		return nil, nil, cli.Exit(fmt.Sprintf("Pool %s does not support expanding data disks", pool.ID()), 1)
+	}
+	return pool, diskPool, nil
+}
+
+func diskExpand(ctx *cli.Context) error {
+	args, err := commandArgs(ctx)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return cli.Exit("Pod ID is required", 1)
+	}
+	if !ctx.IsSet("size") {
+		return cli.Exit("--size is required", 1)
+	}
+	size, relative, err := parseSize(ctx.String("size"))
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+	pool, diskPool, err := newDiskPool(ctx)
+	if err != nil {
+		return err
+	}
+	pod, err := pool.GetPod(ctx.Context, args[0])
+	if err != nil {
+		return fmt.Errorf("failed to get pod: %w", err)
+	}
+	if relative {
+		size += pod.DataDiskSize
+	}
+	if err := internal.CheckDataDiskSize(pod, size); err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+	if err := diskPool.ExpandDataDisk(ctx.Context, pod.ID, size); err != nil {
+		return err
+	}
+	fmt.Printf("Data disk of pod %s expanded from %s to %s\n", pod.ID, humanReadableMemory(pod.DataDiskSize), humanReadableMemory(size))
+	return nil
+}
+
+// sizeUnits are the suffixes parseSize accepts, all of them binary like
+// the sizes pools report
+var sizeUnits = []struct {
+	suffix string
+	scale  float64
+}{
+	{"TIB", 1 << 40}, {"TB", 1 << 40}, {"T", 1 << 40},
+	{"GIB", 1 << 30}, {"GB", 1 << 30}, {"G", 1 << 30},
+	{"MIB", 1 << 20}, {"MB", 1 << 20}, {"M", 1 << 20},
+	{"B", 1},
+}
+
+// parseSize parses a size such as 200G or 1.5TiB, relative is true if it
+// starts with a plus sign
+func parseSize(value string) (size int64, relative bool, err error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	s, relative = strings.CutPrefix(s, "+")
+	scale := float64(1)
+	for _, unit := range sizeUnits {
+		if number, ok := strings.CutSuffix(s, unit.suffix); ok {
+			s, scale = strings.TrimSpace(number), unit.scale
+			break
+		}
+	}
+	number, err := strconv.ParseFloat(s, 64)
+	if err != nil || number <= 0 {
+		return 0, false, fmt.Errorf("invalid size %q, use e.g. 200G or +50G", value)
+	}
+	return int64(number * scale), relative, nil
+}
+
+// podDiskUsage is the usage of the disks of a pod, nil when unknown
+type podDiskUsage struct {
+	system *internal.DiskUsage
+	data   *internal.DiskUsage
+}
+
+// measureDiskUsage runs df on a running pod over SSH
+func measureDiskUsage(ctx context.Context, pod internal.Pod) (podDiskUsage, error) {
+	var usage podDiskUsage
+	if pod.Status != internal.StatusRunning || pod.SSHDomain == "" {
+		return usage, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, diskUsageTimeout)
+	defer cancel()
+	client, err := newSSHClient(ctx, pod)
+	if err != nil {
+		return usage, err
+	}
+	defer client.Close()
+
+	command := "df -P -B1 /"
+	if pod.DataDiskMountPath != "" {
+		command += " " + strconv.Quote(pod.DataDiskMountPath)
+	}
+	output, err := client.ExecWithOutput(ctx, command)
+	if err != nil {
+		return usage, fmt.Errorf("failed to run df: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	usages, err := internal.ParseDF(output)
+	if err != nil {
+		return usage, err
+	}
+	// df prints one line per path, in the order they were given
+	if len(usages) > 0 {
+		usage.system = &usages[0]
+	}
+	if len(usages) > 1 {
+		usage.data = &usages[1]
+	}
+	return usage, nil
+}
+
+// diskUsageCache measures the disk usage of pods and keeps the result for
+// diskUsageTTL, so list --watch does not open SSH sessions on every refresh
+type diskUsageCache struct {
+	measure func(context.Context, internal.Pod) (podDiskUsage, error)
+	now     func() time.Time
+	entries map[string]diskUsageEntry
+}
+
+// diskUsageEntry is a measured disk usage, ok is false when measuring failed
+type diskUsageEntry struct {
+	usage      podDiskUsage
+	ok         bool
+	measuredAt time.Time
+}
+
+func newDiskUsageCache() *diskUsageCache {
+	return &diskUsageCache{
+		measure: measureDiskUsage,
+		now:     time.Now,
+		entries: map[string]diskUsageEntry{},
+	}
+}
+
+// usages returns the disk usage of pods, measuring those without a fresh
+// entry with at most diskUsageSessions SSH sessions at once. Pods that could
+// not be measured are missing from the result, failures are cached too so
+// an unreachable pod is not retried on every refresh
+func (c *diskUsageCache) usages(ctx context.Context, pods []internal.Pod) map[string]podDiskUsage {
+	now := c.now()
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sessions := make(chan struct{}, diskUsageSessions)
+	for _, pod := range pods {
+		if entry, found := c.entries[pod.ID]; found && now.Sub(entry.measuredAt) < diskUsageTTL {
+			continue
+		}
+		wg.Add(1)
+		go func(pod internal.Pod) {
+			defer wg.Done()
+			sessions <- struct{}{}
+			defer func() { <-sessions }()
+			usage, err := c.measure(ctx, pod)
+			if err != nil {
+				logrus.Debugf("failed to measure disk usage of pod %s: %v", pod.ID, err)
+			}
+			mu.Lock()
+			c.entries[pod.ID] = diskUsageEntry{usage: usage, ok: err == nil, measuredAt: now}
+			mu.Unlock()
+		}(pod)
+	}
+	wg.Wait()
+
+	usages := make(map[string]podDiskUsage, len(pods))
+	for _, pod := range pods {
+		if entry := c.entries[pod.ID]; entry.ok {
+			usages[pod.ID] = entry.usage
+		}
+	}
+	return usages
+}
+
+// formatDiskUsage returns e.g. "12.00 GB / 50.00 GB (24%)", or just the
+// provisioned size when the usage is unknown
+func formatDiskUsage(usage *internal.DiskUsage, provisioned int64) string {
+	if usage == nil {
+		if provisioned == 0 {
+			return "-"
+		}
+		return humanReadableMemory(provisioned)
+	}
+	if provisioned == 0 {
+		provisioned = usage.Size
+	}
+	percent := 0.0
+	if usage.Size > 0 {
+		percent = float64(usage.Used) * 100 / float64(usage.Size)
+	}
+	return fmt.Sprintf("%s / %s (%.0f%%)", humanReadableMemory(usage.Used), humanReadableMemory(provisioned), percent)
+}
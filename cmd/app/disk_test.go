@@ -0,0 +1,109 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/funstory-ai/gobun/internal"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		value    string
+		size     int64
+		relative bool
+		wantErr  bool
+	}{
+		{value: "200G", size: 200 << 30},
+		{value: "200g", size: 200 << 30},
+		{value: "200GB", size: 200 << 30},
+		{value: "200GiB", size: 200 << 30},
+		{value: "200 G", size: 200 << 30},
+		{value: "1.5T", size: 3 << 39},
+		{value: "1TiB", size: 1 << 40},
+		{value: "512M", size: 512 << 20},
+		{value: "1024B", size: 1024},
+		{value: "1024", size: 1024},
+		{value: "+50G", size: 50 << 30, relative: true},
+		{value: " +50G ", size: 50 << 30, relative: true},
+		{value: "", wantErr: true},
+		{value: "G", wantErr: true},
+		{value: "0G", wantErr: true},
+		{value: "-50G", wantErr: true},
+		{value: "50X", wantErr: true},
+		{value: "fifty", wantErr: true},
+	}
+	for _, tt := range tests {
+		size, relative, err := parseSize(tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseSize(%q) = %d, %v, want an error", tt.value, size, relative)
+			}
+			continue
+		}
+		if err != nil || size != tt.size || relative != tt.relative {
+			t.Errorf("parseSize(%q) = %d, %v, %v, want %d, %v", tt.value, size, relative, err, tt.size, tt.relative)
+		}
+	}
+}
+
+func TestDiskUsageCache(t *testing.T) {
+	var mu sync.Mutex
+	measured := map[string]int{}
+	running, maxRunning := 0, 0
+	cache := newDiskUsageCache()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+	cache.measure = func(ctx context.Context, pod internal.Pod) (podDiskUsage, error) {
+		mu.Lock()
+		measured[pod.ID]++
+		running++
+		maxRunning = max(maxRunning, running)
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		if pod.ID == "broken" {
+			return podDiskUsage{}, errors.New("connection refused")
+		}
+		return podDiskUsage{data: &internal.DiskUsage{Used: 1}}, nil
+	}
+
+	pods := []internal.Pod{{ID: "broken"}}
+	for i := 0; i < 3*diskUsageSessions; i++ {
+		pods = append(pods, internal.Pod{ID: fmt.Sprint(i)})
+	}
+	usages := cache.usages(context.Background(), pods)
+	if len(usages) != len(pods)-1 {
+		t.Errorf("got %d usages, want %d", len(usages), len(pods)-1)
+	}
+	if _, ok := usages["broken"]; ok {
+		t.Error("got a usage for a pod that could not be measured")
+	}
+	if maxRunning > diskUsageSessions {
+		t.Errorf("measured %d pods at once, want at most %d", maxRunning, diskUsageSessions)
+	}
+
+	// A refresh within the TTL measures nothing again
+	now = now.Add(diskUsageTTL / 2)
+	usages = cache.usages(context.Background(), pods)
+	if len(usages) != len(pods)-1 {
+		t.Errorf("got %d cached usages, want %d", len(usages), len(pods)-1)
+	}
+	for id, count := range measured {
+		if count != 1 {
+			t.Errorf("pod %s was measured %d times within the TTL", id, count)
+		}
+	}
+
+	now = now.Add(diskUsageTTL)
+	cache.usages(context.Background(), pods[:1])
+	if measured["broken"] != 2 || measured["0"] != 1 {
+		t.Errorf("after the TTL measured broken %d and 0 %d times, want 2 and 1", measured["broken"], measured["0"])
+	}
+}
@@ -0,0 +1,121 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/funstory-ai/gobun/internal"
+	"github.com/urfave/cli/v2"
+)
+
+var CommandImage = &cli.Command{
+	Name:  "image",
+	Usage: "Manage the images pods are created from",
+	Subcommands: []*cli.Command{
+		{
+			Name:    "ls",
+			Aliases: []string{"list"},
+			Usage:   "List public and private images",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "private",
+					Usage: "only show private images",
+				},
+			},
+			Action: imageList,
+		},
+		{
+			Name:      "save",
+			Usage:     "Save a pod as a private image, stop the pod first for a consistent image",
+			ArgsUsage: "<pod-id> <image-name>",
+			Action:    imageSave,
+		},
+		{
+			Name:      "rm",
+			Usage:     "Delete one or more private images",
+			ArgsUsage: "<image-id> [image-id ...]",
+			Action:    imageRemove,
+		},
+	},
+}
+
+// newImagePool returns the selected pool if it supports images
+func newImagePool(ctx *cli.Context) (internal.ImagePool, error) {
+	pool, err := newPool(ctx)
+	if err != nil {
+		return nil, err
+	}
+	imagePool, ok := pool.(internal.ImagePool)
+	if !ok {
+		return nil, cli.Exit(fmt.Sprintf("Pool %s does not support images", pool.ID()), 1)
+	}
+	return imagePool, nil
+}
+
+func imageList(ctx *cli.Context) error {
+	pool, err := newImagePool(ctx)
+	if err != nil {
+		return err
+	}
+	images, err := pool.ListImages(ctx.Context)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.Debug)
+	fmt.Fprintln(w, "ID\tNAME\tTYPE\tSTATUS\tSIZE\tPRICE/HOUR\tCREATED")
+	for _, image := range images {
+		if ctx.Bool("private") && image.Type != internal.ImageTypePrivate {
+			continue
+		}
+		created := "-"
+		if image.CreateTimestamp > 0 {
+			created = time.Unix(image.CreateTimestamp, 0).Format(time.DateTime)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%.2f\t%s\n",
+			image.ID,
+			image.Name,
+			image.Type,
+			image.Status,
+			humanReadableMemory(image.Size),
+			image.PricePerHour,
+			created,
+		)
+	}
+	return w.Flush()
+}
+
+func imageSave(ctx *cli.Context) error {
+	if ctx.NArg() != 2 {
+		return cli.Exit("Pod ID and image name are required", 1)
+	}
+	pool, err := newImagePool(ctx)
+	if err != nil {
+		return err
+	}
+	image, err := pool.SaveImage(ctx.Context, ctx.Args().Get(0), ctx.Args().Get(1))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Saving pod %s as image %s (ID: %s)\n", ctx.Args().Get(0), image.Name, image.ID)
+	return nil
+}
+
+func imageRemove(ctx *cli.Context) error {
+	if ctx.NArg() < 1 {
+		return cli.Exit("Image ID is required", 1)
+	}
+	pool, err := newImagePool(ctx)
+	if err != nil {
+		return err
+	}
+	for _, imageID := range ctx.Args().Slice() {
+		if err := pool.DestroyImage(ctx.Context, imageID); err != nil {
+			return err
+		}
+		fmt.Printf("Image %s deleted\n", imageID)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/funstory-ai/gobun/internal"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+var CommandJupyter = &cli.Command{
+	Name:      "jupyter",
+	Usage:     "Print a Jupyter URL of a pod that logs in with its token, or tunnel to it over SSH",
+	ArgsUsage: "<pod-id>",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "tunnel",
+			Usage: "always forward a local port over SSH instead of using the provider URL",
+		},
+		&cli.IntFlag{
+			Name:  "local-port",
+			Usage: "local port of the SSH tunnel",
+			Value: 8888,
+		},
+		&cli.IntFlag{
+			Name:  "remote-port",
+			Usage: "port the Jupyter server listens on inside the pod",
+			Value: 8888,
+		},
+	},
+	Action: jupyter,
+}
+
+// jupyterProbeTimeout bounds checking whether the provider URL is reachable
+const jupyterProbeTimeout = 5 * time.Second
+
+func jupyter(ctx *cli.Context) error {
+	args, err := commandArgs(ctx)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return cli.Exit("Pod ID is required", 1)
+	}
+	pool, err := newPool(ctx)
+	if err != nil {
+		return err
+	}
+	pod, err := pool.GetPod(ctx.Context, args[0])
+	if err != nil {
+		return fmt.Errorf("failed to get pod: %w", err)
+	}
+	if pod.Status != internal.StatusRunning {
+		return cli.Exit(fmt.Sprintf("Pod %s is %s, start it first", pod.ID, pod.Status), 1)
+	}
+
+	if pod.JupyterURL != "" && !ctx.Bool("tunnel") {
+		link, err := jupyterLink(pod.JupyterURL, pod.JupyterToken)
+		if err != nil {
+			return err
+		}
+		err = probeURL(ctx.Context, link)
+		if err == nil {
+			fmt.Println(link)
+			return nil
+		}
+		logrus.Debugf("jupyter url %s is not reachable: %v", pod.JupyterURL, err)
+		fmt.Println("The Jupyter URL of the provider is not reachable, tunneling over SSH")
+	}
+	if pod.SSHDomain == "" {
+		return cli.Exit(fmt.Sprintf("Pod %s has no SSH endpoint to tunnel over", pod.ID), 1)
+	}
+
+	// the tunnel stays open until Ctrl+C, whatever --timeout says
+	sessionCtx := sessionContext(ctx)
+	client, err := newSSHClient(sessionCtx, pod)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	localAddress := fmt.Sprintf("127.0.0.1:%d", ctx.Int("local-port"))
+	link, err := jupyterLink("http://"+localAddress+"/", pod.JupyterToken)
+	if err != nil {
+		return err
+	}
+	fmt.Println(link)
+	fmt.Println("Press Ctrl+C to close the tunnel")
+	err = client.LocalForward(sessionCtx, localAddress, fmt.Sprintf("127.0.0.1:%d", ctx.Int("remote-port")))
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+// jupyterLink adds the token to a Jupyter URL unless it already carries one
+func jupyterLink(rawURL string, token string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse jupyter url: %w", err)
+	}
+	query := u.Query()
+	if token != "" && query.Get("token") == "" {
+		query.Set("token", token)
+		u.RawQuery = query.Encode()
+	}
+	return u.String(), nil
+}
+
+// probeURL checks that a URL answers at all, any HTTP status below 500
+// means that it is reachable
+func probeURL(ctx context.Context, rawURL string) error {
+	ctx, cancel := context.WithTimeout(ctx, jupyterProbeTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("http status %d", resp.StatusCode)
+	}
+	return nil
+}
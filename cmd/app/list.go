@@ -1,12 +1,14 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"text/tabwriter"
 	"time"
 
-	"github.com/funstory-ai/gobun/adaptors/xiangongyun"
+	"github.com/funstory-ai/gobun/internal"
 	"github.com/urfave/cli/v2"
 )
 
@@ -19,54 +21,140 @@ var CommandList = &cli.Command{
 			Aliases: []string{"w"},
 			Usage:   "Watch pods status, refresh every 5 seconds",
 		},
+		&cli.StringSliceFlag{
+			Name:  "status",
+			Usage: "only list pods with this status (creating, running, stopping, stopped or error), can be repeated",
+		},
+		&cli.StringFlag{
+			Name:  "name",
+			Usage: "only list pods whose name contains this",
+		},
+		&cli.BoolFlag{
+			Name:  "disk-usage",
+			Usage: "show how full the data disks of running pods are, measured with df over SSH",
+		},
+		outputFlag,
 	},
 	Action: list,
 }
 
 func list(ctx *cli.Context) error {
-	pool := xiangongyun.NewPool("Bearer " + os.Getenv("XGY_TOKEN"))
+	format, err := outputFormatFromFlags(ctx)
+	if err != nil {
+		return err
+	}
+	options, err := listOptionsFromFlags(ctx)
+	if err != nil {
+		return err
+	}
+	pool, err := newPool(ctx)
+	if err != nil {
+		return err
+	}
+
+	var diskUsages *diskUsageCache
+	if ctx.Bool("disk-usage") {
+		diskUsages = newDiskUsageCache()
+	}
 
 	// Function to display pods
 	displayPods := func() error {
-		pods, err := pool.ListPods()
+		pods, err := pool.ListPods(ctx.Context, options)
 		if err != nil {
 			return err
 		}
-
-		if ctx.Bool("watch") {
-			fmt.Print("\033[H\033[2J")
+		if pods == nil {
+			// Scripts expect an empty array rather than null
+			pods = []internal.Pod{}
 		}
 
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.Debug)
-		fmt.Fprintln(w, "ID\tPOOL ID\tNAME\tSTATUS\tGPU\tGPU MODEL\tMEMORY")
-
-		for _, pod := range pods {
-			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
-				pod.ID,
-				pod.PoolID,
-				pod.Name,
-				pod.Status,
-				pod.GPUCount,
-				pod.GPUModel,
-				humanReadableMemory(pod.MemorySize),
-			)
-		}
-
-		return w.Flush()
+		return printOutput(format, pods, func(wide bool) error {
+			var usages map[string]podDiskUsage
+			if diskUsages != nil {
+				usages = diskUsages.usages(ctx.Context, pods)
+			}
+			if ctx.Bool("watch") {
+				fmt.Print("\033[H\033[2J")
+			}
+			return printPods(pods, usages, wide)
+		})
 	}
 
 	if ctx.Bool("watch") {
 		for {
 			if err := displayPods(); err != nil {
+				if errors.Is(err, context.Canceled) {
+					return nil
+				}
 				return err
 			}
-			time.Sleep(5 * time.Second)
+			select {
+			case <-ctx.Done():
+				if errors.Is(ctx.Err(), context.Canceled) {
+					return nil
+				}
+				return ctx.Err()
+			case <-time.After(5 * time.Second):
+			}
 		}
 	}
 
 	return displayPods()
 }
 
+// printPods prints pods as a table, wide adds where they run and what they
+// cost. Pods missing from usages show their provisioned data disk size.
+func printPods(pods []internal.Pod, usages map[string]podDiskUsage, wide bool) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.Debug)
+	header := "ID\tPOOL ID\tNAME\tSTATUS\tPROGRESS\tGPU\tGPU MODEL\tMEMORY\tDATA DISK\tAUTO-SHUTDOWN"
+	if wide {
+		header += "\tDATA CENTER\tPRICE/HOUR\tIMAGE\tSSH\tCREATED"
+	}
+	fmt.Fprintln(w, header)
+
+	for _, pod := range pods {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s",
+			pod.ID,
+			pod.PoolID,
+			pod.Name,
+			pod.Status,
+			formatProgress(pod),
+			pod.GPUCount,
+			formatGPUModel(pod.GPUModel),
+			humanReadableMemory(pod.MemorySize),
+			formatDiskUsage(usages[pod.ID].data, pod.DataDiskSize),
+			formatAutoShutdown(pod),
+		)
+		if wide {
+			fmt.Fprintf(w, "\t%s\t%.2f\t%s\t%s\t%s",
+				valueOrDash(pod.DataCenterName),
+				pod.PricePerHour,
+				valueOrDash(pod.ImageID),
+				formatSSH(pod),
+				formatCreated(pod),
+			)
+		}
+		fmt.Fprintln(w)
+	}
+
+	return w.Flush()
+}
+
+// listOptionsFromFlags builds the pod filters of list from its flags
+func listOptionsFromFlags(ctx *cli.Context) (internal.ListOptions, error) {
+	options := internal.ListOptions{Name: ctx.String("name")}
+	for _, value := range ctx.StringSlice("status") {
+		status := internal.PodStatus(value)
+		switch status {
+		case internal.StatusCreating, internal.StatusRunning, internal.StatusStopping, internal.StatusStopped, internal.StatusError:
+			options.Statuses = append(options.Statuses, status)
+		default:
+			return options, cli.Exit(fmt.Sprintf("Unknown status %q, use creating, running, stopping, stopped or error", value), 1)
+		}
+	}
+	return options, nil
+}
+
 // humanReadableMemory converts bytes to a human-readable format
 func humanReadableMemory(bytes int64) string {
 	const (
@@ -0,0 +1,125 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/funstory-ai/gobun/internal"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+var CommandOffers = &cli.Command{
+	Name:  "offers",
+	Usage: "List the GPUs that can be created right now",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "gpu",
+			Usage: "only show offers of this GPU model",
+		},
+		&cli.IntFlag{
+			Name:  "count",
+			Usage: "only show offers with at least this many GPUs in stock",
+			Value: 1,
+		},
+		&cli.StringFlag{
+			Name:  "datacenter",
+			Usage: "only show offers in this data center (ID or name)",
+		},
+		&cli.Float64Flag{
+			Name:  "max-price",
+			Usage: "only show offers up to this price per GPU and hour",
+		},
+		&cli.StringFlag{
+			Name:  "sort",
+			Usage: "sort offers by price, stock, gpu or pool",
+			Value: "price",
+		},
+		outputFlag,
+	},
+	Action: offers,
+}
+
+func offers(ctx *cli.Context) error {
+	less, ok := offerSorters[ctx.String("sort")]
+	if !ok {
+		return cli.Exit(fmt.Sprintf("unknown sort key %q, use price, stock, gpu or pool", ctx.String("sort")), 1)
+	}
+	format, err := outputFormatFromFlags(ctx)
+	if err != nil {
+		return err
+	}
+	pools, err := newPools(ctx)
+	if err != nil {
+		return err
+	}
+	filter := internal.OfferFilter{
+		GPUModel:        internal.ParseGPUModel(ctx.String("gpu")),
+		MinStock:        ctx.Int("count"),
+		DataCenter:      ctx.String("datacenter"),
+		MaxPricePerHour: ctx.Float64("max-price"),
+	}
+
+	matched := []internal.Offer{}
+	for _, pool := range pools {
+		offers, err := pool.ListOffers(ctx.Context)
+		if err != nil {
+			if len(pools) == 1 {
+				return fmt.Errorf("failed to list offers: %w", err)
+			}
+			logrus.Warnf("failed to list offers of pool %s: %v", pool.ID(), err)
+			continue
+		}
+		for _, offer := range offers {
+			if filter.Match(offer) {
+				matched = append(matched, offer)
+			}
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool {
+		return less(matched[i], matched[j])
+	})
+
+	return printOutput(format, matched, func(wide bool) error {
+		return printOffers(matched, wide)
+	})
+}
+
+// printOffers prints offers as a table, wide adds the data center ID that
+// create --datacenter accepts
+func printOffers(offers []internal.Offer, wide bool) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.Debug)
+	header := "POOL ID\tGPU MODEL\tVRAM\tDATA CENTER\tSTOCK\tPRICE/GPU/HOUR\tCPU/GPU\tMEMORY/GPU\tDISK/GPU"
+	if wide {
+		header += "\tDATA CENTER ID"
+	}
+	fmt.Fprintln(w, header)
+	for _, offer := range offers {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%.2f\t%d\t%s\t%s",
+			offer.PoolID,
+			formatGPUModel(offer.GPUModel),
+			humanReadableMemory(offer.GPUModel.VRAM()),
+			offer.DataCenterName,
+			offer.Stock,
+			offer.PricePerHour,
+			offer.CPUCoreCount,
+			humanReadableMemory(offer.MemorySize),
+			humanReadableMemory(offer.DataDiskSize),
+		)
+		if wide {
+			fmt.Fprintf(w, "\t%s", valueOrDash(offer.DataCenterID))
+		}
+		fmt.Fprintln(w)
+	}
+	return w.Flush()
+}
+
+// offerSorters order offers by the --sort key, ties keep the pool order
+var offerSorters = map[string]func(a, b internal.Offer) bool{
+	"price": func(a, b internal.Offer) bool { return a.PricePerHour < b.PricePerHour },
+	"stock": func(a, b internal.Offer) bool { return a.Stock > b.Stock },
+	"gpu":   func(a, b internal.Offer) bool { return a.GPUModel < b.GPUModel },
+	"pool":  func(a, b internal.Offer) bool { return a.PoolID < b.PoolID },
+}
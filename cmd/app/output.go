@@ -0,0 +1,174 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/funstory-ai/gobun/internal/utils/jsonpath"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// outputFlag selects how list, create, describe, offers and balance print
+// their result, every format but table and wide is meant for scripts
+var outputFlag = &cli.StringFlag{
+	Name:    "output",
+	Aliases: []string{"o"},
+	Usage:   "output format: table, wide, json, yaml, jsonpath=<path> or go-template=<template>",
+	Value:   "table",
+}
+
+// outputFormat is a parsed --output, arg is the path of jsonpath and the
+// template of go-template
+type outputFormat struct {
+	name string
+	arg  string
+}
+
+// machineReadable tells whether the format is for scripts, which must not
+// get progress messages mixed into stdout
+func (f outputFormat) machineReadable() bool {
+	return f.name != "table" && f.name != "wide"
+}
+
+// outputFormatFromFlags parses --output, it is checked before anything is
+// done so that a typo does not cost a created pod
+func outputFormatFromFlags(ctx *cli.Context) (outputFormat, error) {
+	value := ctx.String("output")
+	name, arg, _ := strings.Cut(value, "=")
+	format := outputFormat{name: name, arg: arg}
+	switch name {
+	case "", "table", "wide", "json", "yaml":
+		if arg != "" {
+			return format, cli.Exit(fmt.Sprintf("Output format %s takes no argument", name), 1)
+		}
+		if name == "" {
+			format.name = "table"
+		}
+	case "jsonpath":
+		// kubectl wraps paths in braces, accept them for familiarity
+		format.arg = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(arg), "{"), "}")
+		if _, err := jsonpath.Parse(format.arg); err != nil {
+			return format, cli.Exit(fmt.Sprintf("Invalid jsonpath: %v", err), 1)
+		}
+	case "go-template":
+		if arg == "" {
+			return format, cli.Exit("Output format go-template requires a template, e.g. go-template='{{.id}}'", 1)
+		}
+		if _, err := template.New("output").Parse(arg); err != nil {
+			return format, cli.Exit(fmt.Sprintf("Invalid go-template: %v", err), 1)
+		}
+	default:
+		return format, cli.Exit(fmt.Sprintf("Unknown output format %q, use table, wide, json, yaml, jsonpath=<path> or go-template=<template>", value), 1)
+	}
+	return format, nil
+}
+
+// messageWriter is where a command prints progress messages, stderr when
+// its output is for scripts
+func messageWriter(ctx *cli.Context) *os.File {
+	if format, err := outputFormatFromFlags(ctx); err == nil && format.machineReadable() {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// printOutput prints value in the format, table prints it for people and
+// is called with wide set for the wide format. Every other format works on
+// the JSON form of value.
+func printOutput(format outputFormat, value interface{}, table func(wide bool) error) error {
+	switch format.name {
+	case "table", "wide":
+		return table(format.name == "wide")
+	case "json":
+		data, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	data, err := genericJSON(value)
+	if err != nil {
+		return err
+	}
+	switch format.name {
+	case "yaml":
+		encoder := yaml.NewEncoder(os.Stdout)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(data); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case "jsonpath":
+		values, err := jsonpath.GetAll(data, format.arg)
+		if err != nil {
+			return err
+		}
+		for _, value := range values {
+			if s, ok := value.(string); ok {
+				fmt.Println(s)
+				continue
+			}
+			line, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(line))
+		}
+		return nil
+	case "go-template":
+		tmpl, err := template.New("output").Parse(format.arg)
+		if err != nil {
+			return err
+		}
+		return tmpl.Execute(os.Stdout, data)
+	default:
+		return fmt.Errorf("unknown output format %q", format.name)
+	}
+}
+
+// genericJSON converts value to maps, slices and plain values through its
+// JSON form, so that yaml, jsonpath and templates see the JSON field names
+func genericJSON(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// Sizes in bytes would print as 5.36870912e+10 when decoded to float64
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return plainNumbers(generic), nil
+}
+
+// plainNumbers replaces the json.Number values in data by int64 or
+// float64, which yaml prints as numbers instead of strings
+func plainNumbers(data interface{}) interface{} {
+	switch node := data.(type) {
+	case map[string]interface{}:
+		for key, value := range node {
+			node[key] = plainNumbers(value)
+		}
+	case []interface{}:
+		for i, value := range node {
+			node[i] = plainNumbers(value)
+		}
+	case json.Number:
+		if n, err := node.Int64(); err == nil {
+			return n
+		}
+		if f, err := node.Float64(); err == nil {
+			return f
+		}
+	}
+	return data
+}
@@ -0,0 +1,237 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/funstory-ai/gobun/adaptors/rest"
+	"github.com/funstory-ai/gobun/internal"
+	bunconfig "github.com/funstory-ai/gobun/internal/config"
+	"github.com/funstory-ai/gobun/internal/utils/fileutil"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	// Register the pool providers
+	_ "github.com/funstory-ai/gobun/adaptors/houdeyun"
+	_ "github.com/funstory-ai/gobun/adaptors/local"
+	_ "github.com/funstory-ai/gobun/adaptors/sim"
+	_ "github.com/funstory-ai/gobun/adaptors/xiangongyun"
+)
+
+// newPool returns the pool selected by --pool, falling back to the
+// default pool from the config file
+func newPool(ctx *cli.Context) (internal.Pool, error) {
+	name := ctx.String("pool")
+	if name == "" {
+		cfg, err := bunconfig.Load()
+		if err != nil {
+			return nil, err
+		}
+		name = cfg.DefaultPool
+	}
+	return internal.NewPool(name)
+}
+
+// newPools returns the pools that a new pod may be placed on, that is the
+// pool selected by --pool, or else the pools listed in the config file,
+// or else the default pool
+func newPools(ctx *cli.Context) ([]internal.Pool, error) {
+	if ctx.String("pool") != "" {
+		pool, err := newPool(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return []internal.Pool{pool}, nil
+	}
+	cfg, err := bunconfig.Load()
+	if err != nil {
+		return nil, err
+	}
+	names := cfg.Pools
+	if len(names) == 0 {
+		names = []string{cfg.DefaultPool}
+	}
+	pools := make([]internal.Pool, 0, len(names))
+	for _, name := range names {
+		pool, err := internal.NewPool(name)
+		if err != nil {
+			// a pool without credentials must not block the others
+			logrus.Warnf("skipping pool %s: %v", name, err)
+			continue
+		}
+		pools = append(pools, pool)
+	}
+	if len(pools) == 0 {
+		return nil, fmt.Errorf("none of the pools %v is usable", names)
+	}
+	return pools, nil
+}
+
+// placePod creates a pod on the cheapest of the candidate pools, falling
+// back to the next one when creation failed without creating anything. The
+// balance of a pool is checked before it is asked to create the pod.
+func placePod(ctx *cli.Context, options internal.PodOptions) (internal.Pod, error) {
+	pools, err := newPools(ctx)
+	if err != nil {
+		return internal.Pod{}, err
+	}
+	// a pool that would ignore an option must not get the pod, it would be
+	// paid for with the wrong configuration, e.g. without the auto-shutdown
+	// the user relies on to stop paying
+	var rejected []error
+	pools = filterPools(pools, func(pool internal.Pool) bool {
+		err := internal.CheckPodOptions(pool, options)
+		if err != nil {
+			rejected = append(rejected, err)
+		}
+		return err == nil
+	})
+	if len(pools) == 0 {
+		return internal.Pod{}, errors.Join(rejected...)
+	}
+	if len(pools) == 1 {
+		// a single pool is tried even without stock in its offers, the
+		// quote only prices the balance check
+		candidate := internal.Candidate{Pool: pools[0]}
+		if quoted := internal.Quote(ctx.Context, pools[0], options); quoted != nil {
+			candidate = *quoted
+		}
+		if err := checkBalance(ctx, candidate); err != nil {
+			return internal.Pod{}, err
+		}
+		return pools[0].CreatePod(ctx.Context, options)
+	}
+	out := messageWriter(ctx)
+	return internal.PlacePod(ctx.Context, pools, options, func(candidate internal.Candidate) error {
+		if candidate.Quoted {
+			fmt.Fprintf(out, "Trying pool %s at %.2f/hour...\n", candidate.Pool.ID(), candidate.PricePerHour)
+		} else {
+			fmt.Fprintf(out, "Trying pool %s...\n", candidate.Pool.ID())
+		}
+		return checkBalance(ctx, candidate)
+	})
+}
+
+// createPodError reports a failed placePod, pointing at the pod that may
+// have been created anyway when the pool returned its ID
+func createPodError(pod internal.Pod, err error) error {
+	if pod.ID == "" {
+		return fmt.Errorf("failed to create pod: %w", err)
+	}
+	return fmt.Errorf("failed to create pod, pod %s on pool %s may exist and cost money, check gobun list and destroy it if needed: %w", pod.ID, pod.PoolID, err)
+}
+
+// podOptionFlags are the flags describing the pod to create
+var podOptionFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "gpu",
+		Usage: "GPU model of the pod, e.g. " + strings.Join(gpuModelNames(), ", "),
+		Value: string(internal.GPUModelRTX4090),
+	},
+	&cli.IntFlag{
+		Name:  "count",
+		Usage: "number of GPUs of the pod",
+		Value: 1,
+	},
+	&cli.StringFlag{
+		Name:  "name",
+		Usage: "name of the pod, the pool chooses one if empty",
+	},
+	&cli.StringFlag{
+		Name:  "disk",
+		Usage: "size of the data disk, e.g. 200G, the pool default if empty",
+	},
+	&cli.StringFlag{
+		Name:  "image",
+		Usage: "ID or name of the image of the pod, as listed by gobun image ls",
+	},
+	&cli.StringFlag{
+		Name:  "datacenter",
+		Usage: "ID or name of the data center of the pod, overrides datacenters in the config file",
+	},
+	&cli.DurationFlag{
+		Name:  "auto-shutdown",
+		Usage: "shut the pod down automatically after this long, e.g. 3h",
+	},
+	&cli.StringFlag{
+		Name:  "auto-shutdown-action",
+		Usage: "what auto-shutdown does to the pod, stop or destroy",
+		Value: string(internal.AutoShutdownStop),
+	},
+}
+
+// filterPools returns the pools that keep returns true for
+func filterPools(pools []internal.Pool, keep func(internal.Pool) bool) []internal.Pool {
+	kept := make([]internal.Pool, 0, len(pools))
+	for _, pool := range pools {
+		if keep(pool) {
+			kept = append(kept, pool)
+		}
+	}
+	return kept
+}
+
+// gpuModelNames returns the names of the known GPU models
+func gpuModelNames() []string {
+	models := internal.GPUModels()
+	names := make([]string, len(models))
+	for i, model := range models {
+		names[i] = string(model)
+	}
+	return names
+}
+
+// formatGPUModel renders a GPU model, flagging models outside of the catalog
+func formatGPUModel(model internal.GPUModel) string {
+	if model == "" || model.Known() {
+		return string(model)
+	}
+	return string(model) + " (unknown)"
+}
+
+// podOptionsFromFlags builds the pod options from podOptionFlags and the
+// preferred data centers of the config file and validates them
+func podOptionsFromFlags(ctx *cli.Context) (internal.PodOptions, error) {
+	cfg, err := bunconfig.Load()
+	if err != nil {
+		return internal.PodOptions{}, err
+	}
+	action, err := internal.ParseAutoShutdownAction(ctx.String("auto-shutdown-action"))
+	if err != nil {
+		return internal.PodOptions{}, cli.Exit(err.Error(), 1)
+	}
+	var dataDiskSize int64
+	if value := ctx.String("disk"); value != "" {
+		size, relative, err := parseSize(value)
+		if err != nil || relative {
+			return internal.PodOptions{}, cli.Exit(fmt.Sprintf("Invalid disk size %q, use e.g. 200G", value), 1)
+		}
+		dataDiskSize = size
+	}
+	options := internal.PodOptions{
+		GPUModel:             internal.ParseGPUModel(ctx.String("gpu")),
+		GPUCount:             ctx.Int("count"),
+		Name:                 ctx.String("name"),
+		DataDiskSize:         dataDiskSize,
+		Image:                ctx.String("image"),
+		DataCenter:           ctx.String("datacenter"),
+		PreferredDataCenters: cfg.DataCenters,
+		AutoShutdown:         ctx.Duration("auto-shutdown"),
+		AutoShutdownAction:   action,
+	}
+	if err := options.Validate(); err != nil {
+		return internal.PodOptions{}, cli.Exit(err.Error(), 1)
+	}
+	return options, nil
+}
+
+// registerRESTPools registers the REST providers described by the mapping
+// files in ~/.config/gobun/providers
+func registerRESTPools() {
+	dir := filepath.Join(fileutil.DefaultConfigDir, "providers")
+	if err := rest.RegisterDir(dir); err != nil {
+		logrus.Warnf("failed to load REST providers from %s: %v", dir, err)
+	}
+}
@@ -0,0 +1,32 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+)
+
+var CommandRestart = &cli.Command{
+	Name:      "restart",
+	Usage:     "Restart one or more running pods",
+	ArgsUsage: "<pod-id> [pod-id ...]",
+	Action:    restart,
+}
+
+func restart(ctx *cli.Context) error {
+	if ctx.NArg() < 1 {
+		return cli.Exit("Pod ID is required", 1)
+	}
+	pool, err := newPool(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, podID := range ctx.Args().Slice() {
+		if err := pool.RestartPod(ctx.Context, podID); err != nil {
+			return fmt.Errorf("failed to restart pod %s: %w", podID, err)
+		}
+		fmt.Printf("Pod %s is restarting\n", podID)
+	}
+	return nil
+}
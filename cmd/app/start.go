@@ -0,0 +1,32 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+)
+
+var CommandStart = &cli.Command{
+	Name:      "start",
+	Usage:     "Start one or more stopped pods",
+	ArgsUsage: "<pod-id> [pod-id ...]",
+	Action:    start,
+}
+
+func start(ctx *cli.Context) error {
+	if ctx.NArg() < 1 {
+		return cli.Exit("Pod ID is required", 1)
+	}
+	pool, err := newPool(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, podID := range ctx.Args().Slice() {
+		if err := pool.StartPod(ctx.Context, podID); err != nil {
+			return fmt.Errorf("failed to start pod %s: %w", podID, err)
+		}
+		fmt.Printf("Pod %s is starting\n", podID)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+)
+
+var CommandStop = &cli.Command{
+	Name:      "stop",
+	Usage:     "Stop one or more pods, keeping their data disk and image",
+	ArgsUsage: "<pod-id> [pod-id ...]",
+	Action:    stop,
+}
+
+func stop(ctx *cli.Context) error {
+	if ctx.NArg() < 1 {
+		return cli.Exit("Pod ID is required", 1)
+	}
+	pool, err := newPool(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, podID := range ctx.Args().Slice() {
+		if err := pool.StopPod(ctx.Context, podID); err != nil {
+			return fmt.Errorf("failed to stop pod %s: %w", podID, err)
+		}
+		fmt.Printf("Pod %s is stopping\n", podID)
+	}
+	return nil
+}
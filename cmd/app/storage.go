@@ -0,0 +1,377 @@
+package app
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/funstory-ai/gobun/internal"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/term"
+)
+
+// remotePrefix marks a path of cp as a storage key instead of a local path
+const remotePrefix = "cos:"
+
+var CommandStorage = &cli.Command{
+	Name:  "storage",
+	Usage: "Manage files in the object storage of the pool, which outlives pods",
+	Subcommands: []*cli.Command{
+		{
+			Name:      "ls",
+			Aliases:   []string{"list"},
+			Usage:     "List the objects whose key starts with prefix",
+			ArgsUsage: "[prefix]",
+			Action:    storageList,
+		},
+		{
+			Name:      "cp",
+			Usage:     "Upload or download files, storage keys are written as cos:<key>, interrupted transfers resume when run again",
+			ArgsUsage: "<src> <dst>",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:    "recursive",
+					Aliases: []string{"r"},
+					Usage:   "copy a directory or every object under a prefix",
+				},
+				&cli.BoolFlag{
+					Name:    "quiet",
+					Aliases: []string{"q"},
+					Usage:   "do not show progress",
+				},
+			},
+			Action: storageCopy,
+		},
+		{
+			Name:      "rm",
+			Usage:     "Delete one or more objects",
+			ArgsUsage: "<key> [key ...]",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:    "recursive",
+					Aliases: []string{"r"},
+					Usage:   "delete every object under the given prefixes",
+				},
+			},
+			Action: storageRemove,
+		},
+		{
+			Name:      "du",
+			Usage:     "Show the size of the objects under prefix, grouped by the next path segment",
+			ArgsUsage: "[prefix]",
+			Action:    storageUsage,
+		},
+	},
+}
+
+// newStorage returns the storage of the selected pool if it has one
+func newStorage(ctx *cli.Context) (internal.Storage, error) {
+	pool, err := newPool(ctx)
+	if err != nil {
+		return nil, err
+	}
+	storagePool, ok := pool.(internal.StoragePool)
+	if !ok {
+		return nil, cli.Exit(fmt.Sprintf("Pool %s does not support storage", pool.ID()), 1)
+	}
+	return storagePool.Storage(ctx.Context)
+}
+
+func storageList(ctx *cli.Context) error {
+	storage, err := newStorage(ctx)
+	if err != nil {
+		return err
+	}
+	objects, err := storage.List(ctx.Context, strings.TrimPrefix(ctx.Args().First(), remotePrefix))
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.Debug)
+	fmt.Fprintln(w, "KEY\tSIZE\tLAST MODIFIED")
+	for _, object := range objects {
+		modified := "-"
+		if object.LastModified > 0 {
+			modified = time.Unix(object.LastModified, 0).Format(time.DateTime)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", object.Key, humanReadableMemory(object.Size), modified)
+	}
+	return w.Flush()
+}
+
+func storageCopy(ctx *cli.Context) error {
+	args, err := commandArgs(ctx)
+	if err != nil {
+		return err
+	}
+	if len(args) != 2 {
+		return cli.Exit("Please provide a source and a destination", 1)
+	}
+	src, dst := args[0], args[1]
+	srcKey, srcRemote := strings.CutPrefix(src, remotePrefix)
+	dstKey, dstRemote := strings.CutPrefix(dst, remotePrefix)
+	if srcRemote == dstRemote {
+		return cli.Exit(fmt.Sprintf("Exactly one of source and destination must be a storage key starting with %s", remotePrefix), 1)
+	}
+	storage, err := newStorage(ctx)
+	if err != nil {
+		return err
+	}
+	showProgress := !ctx.Bool("quiet") && term.IsTerminal(int(os.Stderr.Fd()))
+
+	if dstRemote {
+		files, err := uploadPlan(src, dstKey, ctx.Bool("recursive"))
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			if err := storage.Upload(ctx.Context, file.local, file.key, transferProgress(file.local, showProgress)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	files, err := downloadPlan(ctx, storage, srcKey, dst, ctx.Bool("recursive"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := os.MkdirAll(filepath.Dir(file.local), 0755); err != nil {
+			return err
+		}
+		if err := storage.Download(ctx.Context, file.key, file.local, transferProgress(file.key, showProgress)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// transfer is a local file and the key it is copied to or from
+type transfer struct {
+	local string
+	key   string
+}
+
+// uploadPlan maps the local source to keys, a destination ending with a
+// slash is a prefix that the file names are appended to
+func uploadPlan(src string, dstKey string, recursive bool) ([]transfer, error) {
+	stat, err := os.Stat(src)
+	if err != nil {
+		return nil, err
+	}
+	if dstKey == "" || strings.HasSuffix(dstKey, "/") {
+		dstKey += filepath.Base(src)
+	}
+	if !stat.IsDir() {
+		return []transfer{{local: src, key: dstKey}}, nil
+	}
+	if !recursive {
+		return nil, cli.Exit(fmt.Sprintf("%s is a directory, use -r to copy it", src), 1)
+	}
+
+	var files []transfer
+	err = filepath.WalkDir(src, func(local string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(src, local)
+		if err != nil {
+			return err
+		}
+		files = append(files, transfer{local: local, key: path.Join(dstKey, filepath.ToSlash(rel))})
+		return nil
+	})
+	return files, err
+}
+
+// downloadPlan maps the source key or prefix to local paths, an existing
+// local directory as destination receives the object under its base name
+func downloadPlan(ctx *cli.Context, storage internal.Storage, srcKey string, dst string, recursive bool) ([]transfer, error) {
+	stat, err := os.Stat(dst)
+	dstIsDir := err == nil && stat.IsDir()
+	if !recursive {
+		if srcKey == "" || strings.HasSuffix(srcKey, "/") {
+			return nil, cli.Exit(fmt.Sprintf("%s%s is a prefix, use -r to copy it", remotePrefix, srcKey), 1)
+		}
+		if dstIsDir || strings.HasSuffix(dst, string(filepath.Separator)) {
+			if dst, err = localPath(dst, path.Base(srcKey)); err != nil {
+				return nil, err
+			}
+		}
+		return []transfer{{local: dst, key: srcKey}}, nil
+	}
+
+	prefix := srcKey
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	objects, err := storage.List(ctx.Context, prefix)
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) == 0 {
+		return nil, cli.Exit(fmt.Sprintf("No objects under %s%s", remotePrefix, prefix), 1)
+	}
+	if dstIsDir && prefix != "" {
+		dst = filepath.Join(dst, path.Base(srcKey))
+	}
+	files := make([]transfer, 0, len(objects))
+	for _, object := range objects {
+		rel := strings.TrimPrefix(object.Key, prefix)
+		if rel == "" || strings.HasSuffix(rel, "/") {
+			// Directory markers have nothing to download
+			continue
+		}
+		local, err := localPath(dst, rel)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, transfer{local: local, key: object.Key})
+	}
+	return files, nil
+}
+
+// localPath joins the relative part of an object key to dst, keys like
+// "data/../../.bashrc" that would land outside dst are rejected
+func localPath(dst string, rel string) (string, error) {
+	rel = filepath.Clean(filepath.FromSlash(rel))
+	local := filepath.Join(dst, rel)
+	if filepath.IsAbs(rel) || rel == "." {
+		return "", cli.Exit(fmt.Sprintf("Refusing to download %q, it is not a file under %s", rel, dst), 1)
+	}
+	if inside, err := filepath.Rel(dst, local); err != nil || inside == ".." || strings.HasPrefix(inside, ".."+string(filepath.Separator)) {
+		return "", cli.Exit(fmt.Sprintf("Refusing to download %q, it is outside of %s", rel, dst), 1)
+	}
+	return local, nil
+}
+
+// transferProgress prints the progress of a transfer on one line of stderr,
+// nothing is printed when show is false, e.g. in scripts
+func transferProgress(name string, show bool) internal.Progress {
+	if !show {
+		return nil
+	}
+	start := time.Now()
+	var last time.Time
+	return func(done int64, total int64) {
+		now := time.Now()
+		// total is negative while the size is unknown
+		finished := total >= 0 && done >= total
+		if !finished && now.Sub(last) < 200*time.Millisecond {
+			return
+		}
+		last = now
+		rate := float64(done) / max(now.Sub(start).Seconds(), 0.001)
+		if total < 0 {
+			fmt.Fprintf(os.Stderr, "\r%s  %s  %s/s\033[K", name, humanReadableMemory(done), humanReadableMemory(int64(rate)))
+			return
+		}
+		percent := 100.0
+		if total > 0 {
+			percent = float64(done) * 100 / float64(total)
+		}
+		fmt.Fprintf(os.Stderr, "\r%s  %s / %s  %5.1f%%  %s/s\033[K",
+			name, humanReadableMemory(done), humanReadableMemory(total), percent, humanReadableMemory(int64(rate)))
+		if finished {
+			fmt.Fprintln(os.Stderr)
+		}
+	}
+}
+
+func storageRemove(ctx *cli.Context) error {
+	args, err := commandArgs(ctx)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return cli.Exit("Please provide at least one key", 1)
+	}
+	if ctx.Bool("recursive") {
+		for _, arg := range args {
+			if strings.Trim(strings.TrimPrefix(arg, remotePrefix), "/") == "" {
+				return cli.Exit("Refusing to delete every object, give a prefix", 1)
+			}
+		}
+	}
+	storage, err := newStorage(ctx)
+	if err != nil {
+		return err
+	}
+	for _, arg := range args {
+		key := strings.TrimPrefix(arg, remotePrefix)
+		if !ctx.Bool("recursive") {
+			if err := storage.Remove(ctx.Context, key); err != nil {
+				return err
+			}
+			fmt.Printf("Removed %s\n", key)
+			continue
+		}
+		// A raw prefix would also match data2.bin and database/ for data
+		prefix := strings.TrimSuffix(key, "/") + "/"
+		objects, err := storage.List(ctx.Context, prefix)
+		if err != nil {
+			return err
+		}
+		for _, object := range objects {
+			if err := storage.Remove(ctx.Context, object.Key); err != nil {
+				return err
+			}
+			fmt.Printf("Removed %s\n", object.Key)
+		}
+	}
+	return nil
+}
+
+func storageUsage(ctx *cli.Context) error {
+	storage, err := newStorage(ctx)
+	if err != nil {
+		return err
+	}
+	prefix := strings.TrimPrefix(ctx.Args().First(), remotePrefix)
+	objects, err := storage.List(ctx.Context, prefix)
+	if err != nil {
+		return err
+	}
+
+	type usage struct {
+		size  int64
+		count int
+	}
+	groups := make(map[string]*usage)
+	var total usage
+	for _, object := range objects {
+		name := strings.TrimPrefix(object.Key, prefix)
+		if i := strings.Index(name, "/"); i >= 0 {
+			name = name[:i+1]
+		}
+		group, ok := groups[name]
+		if !ok {
+			group = &usage{}
+			groups[name] = group
+		}
+		group.size += object.Size
+		group.count++
+		total.size += object.Size
+		total.count++
+	}
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.Debug)
+	fmt.Fprintln(w, "PREFIX\tOBJECTS\tSIZE")
+	for _, name := range names {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", prefix+name, groups[name].count, humanReadableMemory(groups[name].size))
+	}
+	fmt.Fprintf(w, "TOTAL\t%d\t%s\n", total.count, humanReadableMemory(total.size))
+	return w.Flush()
+}
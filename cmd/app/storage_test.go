@@ -0,0 +1,60 @@
+package app
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/funstory-ai/gobun/internal"
+	"github.com/urfave/cli/v2"
+)
+
+// listStorage is a storage that only lists objects
+type listStorage struct {
+	internal.Storage
+	objects []internal.Object
+}
+
+func (s listStorage) List(ctx context.Context, prefix string) ([]internal.Object, error) {
+	return s.objects, nil
+}
+
+func TestDownloadPlan(t *testing.T) {
+	// dst exists, so the prefix is downloaded into dst/data
+	dst := t.TempDir()
+	ctx := cli.NewContext(cli.NewApp(), nil, nil)
+	ctx.Context = context.Background()
+
+	storage := listStorage{objects: []internal.Object{
+		{Key: "data/a.txt"},
+		{Key: "data/sub/"},
+		{Key: "data/sub/b.txt"},
+	}}
+	files, err := downloadPlan(ctx, storage, "data", dst, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []transfer{
+		{local: filepath.Join(dst, "data", "a.txt"), key: "data/a.txt"},
+		{local: filepath.Join(dst, "data", "sub", "b.txt"), key: "data/sub/b.txt"},
+	}
+	if len(files) != len(want) {
+		t.Fatalf("got %v, want %v", files, want)
+	}
+	for i := range want {
+		if files[i] != want[i] {
+			t.Errorf("got %v, want %v", files[i], want[i])
+		}
+	}
+
+	for _, key := range []string{"data/../../.bashrc", "data/sub/../../../.bashrc", "data//etc/passwd/.."} {
+		storage := listStorage{objects: []internal.Object{{Key: key}}}
+		if files, err := downloadPlan(ctx, storage, "data", dst, true); err == nil {
+			t.Errorf("key %q: got %v, want an error", key, files)
+		}
+	}
+
+	if files, err := downloadPlan(ctx, listStorage{}, "data/..", dst+string(filepath.Separator), false); err == nil {
+		t.Errorf("key data/..: got %v, want an error", files)
+	}
+}
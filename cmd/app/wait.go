@@ -0,0 +1,65 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/funstory-ai/gobun/internal"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/term"
+)
+
+// waitForPod waits until a newly created or started pod is running and
+// shows its progress, updated in place on a terminal and one line per
+// change otherwise. Progress goes to stderr when the output is for scripts.
+func waitForPod(ctx *cli.Context, pod internal.Pod) (internal.Pod, error) {
+	out := messageWriter(ctx)
+	fmt.Fprintln(out, "Waiting for pod to be ready...")
+	interactive := term.IsTerminal(int(out.Fd()))
+	last := ""
+	waiter := internal.Waiter{
+		OnProgress: func(progress internal.WaitProgress) {
+			line := formatWaitProgress(progress)
+			if interactive {
+				fmt.Fprintf(out, "\r%s\033[K", line)
+				return
+			}
+			// Elapsed time alone is no news in logs
+			status := fmt.Sprintf("%s %d", progress.Pod.Status, progress.Pod.Progress)
+			if status != last {
+				fmt.Fprintln(out, line)
+				last = status
+			}
+		},
+	}
+	pod, err := waiter.Wait(ctx.Context, pod)
+	if interactive {
+		fmt.Fprintln(out)
+	}
+	if err != nil {
+		return pod, err
+	}
+	fmt.Fprintln(out, "Pod is now running!")
+	return pod, nil
+}
+
+// formatWaitProgress returns e.g. "creating  45%  elapsed 1m5s  remaining ~1m20s"
+func formatWaitProgress(progress internal.WaitProgress) string {
+	line := string(progress.Pod.Status)
+	if progress.Pod.Status == internal.StatusCreating && progress.Pod.Progress > 0 {
+		line += fmt.Sprintf("  %d%%", progress.Pod.Progress)
+	}
+	line += "  elapsed " + progress.Elapsed.Round(time.Second).String()
+	if progress.Estimated && progress.Pod.Status == internal.StatusCreating {
+		line += "  remaining ~" + progress.Remaining.Round(time.Second).String()
+	}
+	return line
+}
+
+// formatProgress returns the progress column of a pod
+func formatProgress(pod internal.Pod) string {
+	if pod.Status != internal.StatusCreating || pod.Progress == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d%%", pod.Progress)
+}
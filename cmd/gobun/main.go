@@ -1,15 +1,22 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/funstory-ai/gobun/cmd/app"
 )
 
 func run(args []string) error {
+	// Cancel in-flight requests on Ctrl-C or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	app := app.New()
-	return app.Run(args)
+	return app.RunContext(ctx, args)
 }
 
 func handleErr(err error) {
@@ -0,0 +1,30 @@
+package internal
+
+import (
+	"context"
+	"time"
+)
+
+// Balance is the prepaid balance of an account
+type Balance struct {
+	PoolID   string  `json:"pool_id"`
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+// Charge is an amount billed to the account
+type Charge struct {
+	PoolID      string
+	Timestamp   int64
+	PodID       string
+	Description string
+	Amount      float64
+}
+
+// BillingPool is implemented by pools that report the account balance and
+// charges, callers type-assert a Pool to find out
+type BillingPool interface {
+	GetBalance(ctx context.Context) (Balance, error)
+	// ListCharges returns the charges since the given time, newest first
+	ListCharges(ctx context.Context, since time.Time) ([]Charge, error)
+}
@@ -1,8 +1,63 @@
 package config
 
+import (
+	"os"
+
+	"github.com/cockroachdb/errors"
+	"github.com/funstory-ai/gobun/internal/utils/fileutil"
+	"gopkg.in/yaml.v3"
+)
+
 type UpState string
 
 const (
 	PrivateKeyFile = "id_rsa_gobun"
 	PublicKeyFile  = "id_rsa_gobun.pub"
+	ConfigFile     = "config.yaml"
+
+	// DefaultPool is used when neither --pool nor the config file picks one
+	DefaultPool = "xiangongyun"
+	// DefaultLowBalanceHours is the default of Config.LowBalanceHours
+	DefaultLowBalanceHours = 24
 )
+
+// Config is the user configuration stored in ~/.config/gobun/config.yaml
+type Config struct {
+	// DefaultPool is the pool used when --pool is not given
+	DefaultPool string `yaml:"default_pool"`
+	// Pools are the pools that create and up compare when --pool is not
+	// given, the pod is placed on the cheapest one that has stock
+	Pools []string `yaml:"pools"`
+	// DataCenters are the IDs or names of the preferred data centers, new
+	// pods go to the first of them that has stock unless --datacenter is given
+	DataCenters []string `yaml:"datacenters"`
+	// LowBalanceHours makes create and up warn when the balance would run
+	// out within this many hours, 0 disables the warning
+	LowBalanceHours float64 `yaml:"low_balance_hours"`
+}
+
+// Load reads the config file, a missing file yields the default config
+func Load() (Config, error) {
+	cfg := Config{
+		DefaultPool:     DefaultPool,
+		LowBalanceHours: DefaultLowBalanceHours,
+	}
+	path, err := fileutil.ConfigFile(ConfigFile)
+	if err != nil {
+		return cfg, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return cfg, errors.Wrapf(err, "reading config %s failed", path)
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, errors.Wrapf(err, "parsing config %s failed", path)
+	}
+	if cfg.DefaultPool == "" {
+		cfg.DefaultPool = DefaultPool
+	}
+	return cfg, nil
+}
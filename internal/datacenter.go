@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// DataCenter is a location of a pool where pods can be created
+type DataCenter struct {
+	ID     string
+	PoolID string
+	Name   string
+	Region string
+	// Stock is the number of available GPUs per model
+	Stock map[GPUModel]int
+}
+
+// DataCenterPool is implemented by pools with several data centers,
+// callers type-assert a Pool to find out
+type DataCenterPool interface {
+	ListDataCenters(ctx context.Context) ([]DataCenter, error)
+}
+
+// MatchDataCenter tells whether ref is the ID or, ignoring case, the name of a data center
+func MatchDataCenter(ref string, id string, name string) bool {
+	return ref == id || (name != "" && strings.EqualFold(ref, name))
+}
+
+// ChooseOffer returns the offer a pod with the options should be created
+// from. Only offers of the GPU model with enough stock qualify. With
+// options.DataCenter the offer must be in that data center, otherwise the
+// first of options.PreferredDataCenters that qualifies wins and the
+// cheapest offer is the fallback. ErrOutOfStock is returned if nothing qualifies.
+func ChooseOffer(offers []Offer, options PodOptions) (Offer, error) {
+	filter := OfferFilter{
+		GPUModel:   options.GPUModel,
+		MinStock:   options.GPUCount,
+		DataCenter: options.DataCenter,
+	}
+	var qualified []Offer
+	for _, offer := range offers {
+		if filter.Match(offer) {
+			qualified = append(qualified, offer)
+		}
+	}
+	if len(qualified) == 0 {
+		if options.DataCenter != "" {
+			return Offer{}, fmt.Errorf("%w: no %d x %s in data center %s", ErrOutOfStock, options.GPUCount, options.GPUModel, options.DataCenter)
+		}
+		return Offer{}, fmt.Errorf("%w: no %d x %s", ErrOutOfStock, options.GPUCount, options.GPUModel)
+	}
+	for _, ref := range options.PreferredDataCenters {
+		for _, offer := range qualified {
+			if MatchDataCenter(ref, offer.DataCenterID, offer.DataCenterName) {
+				return offer, nil
+			}
+		}
+	}
+	best := qualified[0]
+	for _, offer := range qualified[1:] {
+		if offer.PricePerHour < best.PricePerHour {
+			best = offer
+		}
+	}
+	return best, nil
+}
@@ -0,0 +1,74 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DiskPool is implemented by pools whose data disks can be expanded,
+// callers type-assert a Pool to find out
+type DiskPool interface {
+	// ExpandDataDisk grows the data disk of a pod to size bytes, disks
+	// cannot shrink
+	ExpandDataDisk(ctx context.Context, podID string, size int64) error
+}
+
+// CheckDataDiskSize returns an error if the data disk of pod cannot be
+// expanded to size bytes, Pod.ExpandableDataDiskSize is how much it can
+// still grow
+func CheckDataDiskSize(pod Pod, size int64) error {
+	if size <= pod.DataDiskSize {
+		return fmt.Errorf("the data disk of pod %s is %.1f GiB already, it can only grow", pod.ID, gibibytes(pod.DataDiskSize))
+	}
+	if pod.ExpandableDataDiskSize > 0 && size > pod.DataDiskSize+pod.ExpandableDataDiskSize {
+		return fmt.Errorf("the data disk of pod %s can grow to at most %.1f GiB", pod.ID, gibibytes(pod.DataDiskSize+pod.ExpandableDataDiskSize))
+	}
+	return nil
+}
+
+func gibibytes(size int64) float64 {
+	return float64(size) / (1 << 30)
+}
+
+// DiskUsage is the usage of a file system in bytes
+type DiskUsage struct {
+	MountPath string
+	Size      int64
+	Used      int64
+	Available int64
+}
+
+// ParseDF parses the output of df -P -B1, one DiskUsage per path given to df
+func ParseDF(output []byte) ([]DiskUsage, error) {
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if len(lines) < 2 {
+		return nil, fmt.Errorf("unexpected df output: %q", output)
+	}
+	usages := make([]DiskUsage, 0, len(lines)-1)
+	for _, line := range lines[1:] {
+		// Filesystem 1-blocks Used Available Capacity Mounted on, counted
+		// from the end since file system names may contain spaces
+		fields := strings.Fields(line)
+		if len(fields) < 6 {
+			return nil, fmt.Errorf("unexpected df line: %q", line)
+		}
+		n := len(fields)
+		var numbers [3]int64
+		for i, field := range fields[n-5 : n-2] {
+			number, err := strconv.ParseInt(field, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("unexpected df line: %q", line)
+			}
+			numbers[i] = number
+		}
+		usages = append(usages, DiskUsage{
+			MountPath: fields[n-1],
+			Size:      numbers[0],
+			Used:      numbers[1],
+			Available: numbers[2],
+		})
+	}
+	return usages, nil
+}
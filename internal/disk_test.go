@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDF(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    []DiskUsage
+		wantErr bool
+	}{
+		{
+			name: "system and data disk",
+			output: `Filesystem     1-blocks        Used   Available Capacity Mounted on
+overlay      107374182400 42949672960 64424509440      40% /
+/dev/vdb     214748364800 10737418240 204010946560       5% /root/data
+`,
+			want: []DiskUsage{
+				{MountPath: "/", Size: 107374182400, Used: 42949672960, Available: 64424509440},
+				{MountPath: "/root/data", Size: 214748364800, Used: 10737418240, Available: 204010946560},
+			},
+		},
+		{
+			name: "file system name with spaces",
+			output: `Filesystem 1-blocks Used Available Capacity Mounted on
+my nfs share 1000 400 600 40% /mnt/share
+`,
+			want: []DiskUsage{{MountPath: "/mnt/share", Size: 1000, Used: 400, Available: 600}},
+		},
+		{name: "header only", output: "Filesystem 1-blocks Used Available Capacity Mounted on\n", wantErr: true},
+		{name: "empty", output: "", wantErr: true},
+		{name: "error message", output: "df: /root/data: No such file or directory\nsecond line\n", wantErr: true},
+		{name: "human readable sizes", output: "Filesystem Size Used Avail Use% Mounted on\noverlay 100G 40G 60G 40% /\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDF([]byte(tt.output))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseDF = %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDF = %+v, %v, want %+v", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package internal
+
+import (
+	"errors"
+	"net"
+)
+
+// Pools wrap these errors with %w so callers can react to a failure with
+// errors.Is instead of matching provider messages
+var (
+	// ErrPodNotFound means the pod does not exist or was already destroyed
+	ErrPodNotFound = errors.New("pod not found")
+	// ErrOutOfStock means the pool has not enough GPUs of the requested model
+	ErrOutOfStock = errors.New("out of stock")
+	// ErrUnauthorized means the credentials are missing, invalid or expired
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrInsufficientBalance means the account cannot pay for the request
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	// ErrRateLimited means the provider rejected the request for being sent too often
+	ErrRateLimited = errors.New("rate limited")
+	// ErrTransient means a temporary provider failure, the request may succeed later
+	ErrTransient = errors.New("transient provider error")
+)
+
+// IsRetryable reports whether err is worth retrying later as is
+func IsRetryable(err error) bool {
+	return errors.Is(err, ErrRateLimited) || errors.Is(err, ErrTransient)
+}
+
+// NothingCreated reports whether a failed CreatePod certainly created no
+// pod, so that another pool may be tried. A timeout or a 5xx may come after
+// the provider accepted the request and must not lead to a second pod.
+func NothingCreated(err error) bool {
+	return errors.Is(err, ErrOutOfStock) || errors.Is(err, ErrRateLimited) || IsDialError(err)
+}
+
+// IsDialError reports whether err happened before a connection was made
+func IsDialError(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
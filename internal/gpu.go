@@ -0,0 +1,131 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+const gib = 1 << 30
+
+// GPUInfo describes a known GPU model
+type GPUInfo struct {
+	Model GPUModel
+	// VRAM is the memory of one GPU in bytes
+	VRAM int64
+	// Aliases are other names users and providers use for the model,
+	// matched ignoring case, spaces, dashes and underscores
+	Aliases []string
+}
+
+// gpuCatalog lists the GPU models gobun knows, models outside of it are
+// still accepted from providers but reported as unknown
+var gpuCatalog = []GPUInfo{
+	{Model: GPUModelA100_40G, VRAM: 40 * gib, Aliases: []string{"A100", "A100-PCIE-40GB", "A100-SXM4-40GB"}},
+	{Model: GPUModelA100_80G, VRAM: 80 * gib, Aliases: []string{"A100-PCIE-80GB", "A100-SXM4-80GB"}},
+	{Model: GPUModelA800_40G, VRAM: 40 * gib, Aliases: []string{"A800", "A800-PCIE-40GB"}},
+	{Model: GPUModelA800_80G, VRAM: 80 * gib, Aliases: []string{"A800-PCIE-80GB", "A800-SXM4-80GB"}},
+	{Model: GPUModelH100_80G, VRAM: 80 * gib, Aliases: []string{"H100", "H100-SXM5-80GB", "H100-80GB-HBM3", "H100-PCIE"}},
+	{Model: GPUModelH800_80G, VRAM: 80 * gib, Aliases: []string{"H800", "H800-SXM5-80GB", "H800-PCIE"}},
+	{Model: GPUModelL40S, VRAM: 48 * gib, Aliases: []string{"L40S-48GB"}},
+	{Model: GPUModelRTX4090, VRAM: 24 * gib, Aliases: []string{"4090", "RTX 4090", "GeForce RTX 4090"}},
+	{Model: GPUModelRTX4090_D, VRAM: 24 * gib, Aliases: []string{"4090D", "RTX 4090 D", "GeForce RTX 4090 D"}},
+	{Model: GPUModelRTX4080, VRAM: 16 * gib, Aliases: []string{"4080", "RTX 4080", "GeForce RTX 4080"}},
+	{Model: GPUModelRTX3090, VRAM: 24 * gib, Aliases: []string{"3090", "RTX 3090", "GeForce RTX 3090"}},
+}
+
+// normalizeGPUName drops case, spaces, dashes and underscores and a
+// leading vendor name, so "NVIDIA GeForce RTX 4090" matches "geforce-rtx4090"
+func normalizeGPUName(name string) string {
+	name = strings.ToLower(name)
+	name = strings.NewReplacer(" ", "", "-", "", "_", "").Replace(name)
+	return strings.TrimPrefix(name, "nvidia")
+}
+
+// LookupGPU returns the catalog entry whose model or alias is name
+func LookupGPU(name string) (GPUInfo, bool) {
+	normalized := normalizeGPUName(name)
+	for _, info := range gpuCatalog {
+		if normalizeGPUName(string(info.Model)) == normalized {
+			return info, true
+		}
+		for _, alias := range info.Aliases {
+			if normalizeGPUName(alias) == normalized {
+				return info, true
+			}
+		}
+	}
+	return GPUInfo{}, false
+}
+
+// GPUModels returns the known GPU models in catalog order
+func GPUModels() []GPUModel {
+	models := make([]GPUModel, len(gpuCatalog))
+	for i, info := range gpuCatalog {
+		models[i] = info.Model
+	}
+	return models
+}
+
+// ParseGPUModel returns the known model that name or one of its aliases
+// stands for, unknown names are kept as they are
+func ParseGPUModel(name string) GPUModel {
+	if info, ok := LookupGPU(name); ok {
+		return info.Model
+	}
+	return GPUModel(strings.TrimSpace(name))
+}
+
+// Known tells whether the model is in the catalog
+func (m GPUModel) Known() bool {
+	info, ok := LookupGPU(string(m))
+	return ok && info.Model == m
+}
+
+// VRAM returns the memory of one GPU in bytes, zero for unknown models
+func (m GPUModel) VRAM() int64 {
+	info, _ := LookupGPU(string(m))
+	return info.VRAM
+}
+
+// GPUNames maps GPU models to the names a provider uses for them in both directions
+type GPUNames struct {
+	names  map[GPUModel]string
+	models map[string]GPUModel
+}
+
+// NewGPUNames creates the mapping from the provider name of each model
+func NewGPUNames(names map[GPUModel]string) *GPUNames {
+	g := &GPUNames{
+		names:  make(map[GPUModel]string, len(names)),
+		models: make(map[string]GPUModel, len(names)),
+	}
+	for model, name := range names {
+		g.names[model] = name
+		g.models[name] = model
+	}
+	return g
+}
+
+// Name returns the provider name of a model. A model outside of the
+// catalog is passed through as is, so that a raw provider name reported
+// by the provider itself, e.g. in an offer, can be used to create a pod.
+func (g *GPUNames) Name(model GPUModel) (string, error) {
+	model = ParseGPUModel(string(model))
+	if name, ok := g.names[model]; ok {
+		return name, nil
+	}
+	if !model.Known() && model != "" {
+		return string(model), nil
+	}
+	return "", fmt.Errorf("unsupported gpu model: %s", model)
+}
+
+// Model returns the model of a provider name. Names without a mapping are
+// looked up in the catalog by alias and otherwise kept as they are, such
+// models report false from Known.
+func (g *GPUNames) Model(name string) GPUModel {
+	if model, ok := g.models[name]; ok {
+		return model
+	}
+	return ParseGPUModel(name)
+}
@@ -0,0 +1,125 @@
+package internal
+
+import "testing"
+
+func TestParseGPUModel(t *testing.T) {
+	tests := []struct {
+		name string
+		want GPUModel
+	}{
+		{"RTX4090", GPUModelRTX4090},
+		{"4090", GPUModelRTX4090},
+		{"rtx-4090", GPUModelRTX4090},
+		{"NVIDIA GeForce RTX 4090", GPUModelRTX4090},
+		{"4090D", GPUModelRTX4090_D},
+		{"GeForce RTX 4090D", GPUModelRTX4090_D},
+		{"A100", GPUModelA100_40G},
+		{"NVIDIA A100-SXM4-80GB", GPUModelA100_80G},
+		{"a800_80g", GPUModelA800_80G},
+		{"H100-80GB-HBM3", GPUModelH100_80G},
+		{"L40S", GPUModelL40S},
+		{" Tesla T4 ", GPUModel("Tesla T4")},
+		{"NVIDIA H20", GPUModel("NVIDIA H20")},
+	}
+	for _, tt := range tests {
+		if got := ParseGPUModel(tt.name); got != tt.want {
+			t.Errorf("ParseGPUModel(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGPUCatalog(t *testing.T) {
+	// every model and alias must stand for exactly one catalog entry
+	owners := make(map[string]GPUModel)
+	for _, info := range gpuCatalog {
+		if info.VRAM <= 0 {
+			t.Errorf("%s has no VRAM", info.Model)
+		}
+		if !info.Model.Known() {
+			t.Errorf("%s is not known", info.Model)
+		}
+		for _, name := range append([]string{string(info.Model)}, info.Aliases...) {
+			normalized := normalizeGPUName(name)
+			if owner, ok := owners[normalized]; ok && owner != info.Model {
+				t.Errorf("%q names both %s and %s", name, owner, info.Model)
+			}
+			owners[normalized] = info.Model
+			if got := ParseGPUModel(name); got != info.Model {
+				t.Errorf("ParseGPUModel(%q) = %s, want %s", name, got, info.Model)
+			}
+		}
+	}
+	if len(GPUModels()) != len(gpuCatalog) {
+		t.Errorf("GPUModels returned %d models, the catalog has %d", len(GPUModels()), len(gpuCatalog))
+	}
+
+	tests := []struct {
+		model GPUModel
+		known bool
+		vram  int64
+	}{
+		{GPUModelRTX4090, true, 24 << 30},
+		{GPUModelA100_80G, true, 80 << 30},
+		{GPUModelL40S, true, 48 << 30},
+		// aliases are not models
+		{GPUModel("4090"), false, 24 << 30},
+		{GPUModel("Tesla T4"), false, 0},
+		{GPUModel(""), false, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.model.Known(); got != tt.known {
+			t.Errorf("%q.Known() = %v, want %v", tt.model, got, tt.known)
+		}
+		if got := tt.model.VRAM(); got != tt.vram {
+			t.Errorf("%q.VRAM() = %d, want %d", tt.model, got, tt.vram)
+		}
+	}
+}
+
+func TestGPUNames(t *testing.T) {
+	names := NewGPUNames(map[GPUModel]string{
+		GPUModelRTX4090:  "NVIDIA GeForce RTX 4090",
+		GPUModelA100_80G: "A100 80G",
+	})
+	nameTests := []struct {
+		model   GPUModel
+		want    string
+		wantErr bool
+	}{
+		{model: GPUModelRTX4090, want: "NVIDIA GeForce RTX 4090"},
+		{model: "4090", want: "NVIDIA GeForce RTX 4090"},
+		{model: GPUModelA100_80G, want: "A100 80G"},
+		// unknown models are raw provider names
+		{model: "NVIDIA H20", want: "NVIDIA H20"},
+		{model: GPUModelH800_80G, wantErr: true},
+		{model: "", wantErr: true},
+	}
+	for _, tt := range nameTests {
+		name, err := names.Name(tt.model)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Name(%q) = %q, want an error", tt.model, name)
+			}
+			continue
+		}
+		if err != nil || name != tt.want {
+			t.Errorf("Name(%q) = %q, %v, want %q", tt.model, name, err, tt.want)
+		}
+	}
+
+	modelTests := []struct {
+		name string
+		want GPUModel
+	}{
+		{"NVIDIA GeForce RTX 4090", GPUModelRTX4090},
+		{"A100 80G", GPUModelA100_80G},
+		// names without a mapping are looked up in the catalog
+		{"H800-SXM5-80GB", GPUModelH800_80G},
+		{"NVIDIA H20", GPUModel("NVIDIA H20")},
+	}
+	for _, tt := range modelTests {
+		if got := names.Model(tt.name); got != tt.want {
+			t.Errorf("Model(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
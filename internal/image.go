@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+)
+
+type ImageType string
+
+const (
+	ImageTypePublic  ImageType = "public"
+	ImageTypePrivate ImageType = "private"
+)
+
+// Image is a system image that pods can be created from
+type Image struct {
+	ID     string
+	PoolID string
+	Name   string
+	Type   ImageType
+	// Size is in bytes, zero when the provider does not report it
+	Size int64
+	// PricePerHour is charged on top of the pod price, zero for free images
+	PricePerHour    float64
+	Status          string
+	CreateTimestamp int64
+}
+
+// ImagePool is implemented by pools that can list, save and delete images,
+// callers type-assert a Pool to find out
+type ImagePool interface {
+	// ListImages returns the public images and the private images of the account
+	ListImages(ctx context.Context) ([]Image, error)
+	// SaveImage saves a pod as a private image with the given name
+	SaveImage(ctx context.Context, podID string, name string) (Image, error)
+	// DestroyImage deletes a private image
+	DestroyImage(ctx context.Context, imageID string) error
+}
+
+// FindImage returns the image whose ID or name is ref. An ID wins over a
+// name, a name shared by several images is an error
+func FindImage(images []Image, ref string) (Image, error) {
+	var matches []Image
+	for _, image := range images {
+		if image.ID == ref {
+			return image, nil
+		}
+		if image.Name == ref {
+			matches = append(matches, image)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Image{}, fmt.Errorf("image %q not found", ref)
+	case 1:
+		return matches[0], nil
+	default:
+		return Image{}, fmt.Errorf("image name %q is ambiguous, it matches %d images, use the image ID", ref, len(matches))
+	}
+}
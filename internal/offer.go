@@ -0,0 +1,47 @@
+package internal
+
+// Offer is a GPU configuration that a pool can create right now
+type Offer struct {
+	PoolID   string   `json:"pool_id"`
+	GPUModel GPUModel `json:"gpu_model"`
+	// DataCenterID and DataCenterName tell where the GPUs are, they are
+	// empty for pools without data centers
+	DataCenterID   string `json:"data_center_id"`
+	DataCenterName string `json:"data_center_name"`
+	// Stock is the number of GPUs of the model that are available
+	Stock int `json:"stock"`
+	// PricePerHour is the hourly price of one GPU
+	PricePerHour float64 `json:"price_per_hour"`
+	// CPUCoreCount, MemorySize and DataDiskSize come with each GPU, sizes are in bytes
+	CPUCoreCount int   `json:"cpu_core_count"`
+	MemorySize   int64 `json:"memory_size"`
+	DataDiskSize int64 `json:"data_disk_size"`
+}
+
+// OfferFilter selects offers, zero fields match everything
+type OfferFilter struct {
+	GPUModel GPUModel
+	// MinStock is the number of GPUs that must be available
+	MinStock int
+	// DataCenter matches the data center ID or name
+	DataCenter string
+	// MaxPricePerHour is the highest acceptable price of one GPU
+	MaxPricePerHour float64
+}
+
+// Match tells whether the offer passes the filter
+func (f OfferFilter) Match(offer Offer) bool {
+	if f.GPUModel != "" && offer.GPUModel != f.GPUModel {
+		return false
+	}
+	if offer.Stock < f.MinStock {
+		return false
+	}
+	if f.DataCenter != "" && !MatchDataCenter(f.DataCenter, offer.DataCenterID, offer.DataCenterName) {
+		return false
+	}
+	if f.MaxPricePerHour > 0 && offer.PricePerHour > f.MaxPricePerHour {
+		return false
+	}
+	return true
+}
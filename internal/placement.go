@@ -0,0 +1,99 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Candidate is a pool that may satisfy a pod request
+type Candidate struct {
+	Pool Pool
+	// PricePerHour is the quoted price of the whole pod, valid if Quoted is true
+	PricePerHour float64
+	// Quoted is false for pools that failed to list offers, they are tried last
+	Quoted bool
+}
+
+// RankPools asks every pool for its offers and returns the pools that can
+// satisfy the options, cheapest first. Pools that fail to list offers are
+// kept after the quoted ones in their original order, pools whose offers
+// show no stock for the request are dropped.
+func RankPools(ctx context.Context, pools []Pool, options PodOptions) []Candidate {
+	candidates := make([]*Candidate, len(pools))
+	var wg sync.WaitGroup
+	for i, pool := range pools {
+		wg.Add(1)
+		go func(i int, pool Pool) {
+			defer wg.Done()
+			candidates[i] = Quote(ctx, pool, options)
+		}(i, pool)
+	}
+	wg.Wait()
+
+	ranked := make([]Candidate, 0, len(candidates))
+	for _, candidate := range candidates {
+		if candidate != nil {
+			ranked = append(ranked, *candidate)
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Quoted != ranked[j].Quoted {
+			return ranked[i].Quoted
+		}
+		return ranked[i].Quoted && ranked[i].PricePerHour < ranked[j].PricePerHour
+	})
+	return ranked
+}
+
+// Quote returns the candidate of a pool, or nil if the pool cannot satisfy the options
+func Quote(ctx context.Context, pool Pool, options PodOptions) *Candidate {
+	offers, err := pool.ListOffers(ctx)
+	if err != nil {
+		// the pool may still be able to create pods, try it last
+		return &Candidate{Pool: pool}
+	}
+	offer, err := ChooseOffer(offers, options)
+	if err != nil {
+		return nil
+	}
+	return &Candidate{Pool: pool, PricePerHour: offer.PricePerHour * float64(options.GPUCount), Quoted: true}
+}
+
+// PlacePod creates the pod on the cheapest pool that can satisfy the
+// options and falls back to the next candidate if creation failed before
+// anything was created, see NothingCreated. Any other failure stops it, as
+// does a failure that returned the ID of a created pod, which is returned.
+// onAttempt, if not nil, is called before each attempt, an error from it
+// stops placement before the candidate is asked to create the pod.
+func PlacePod(ctx context.Context, pools []Pool, options PodOptions, onAttempt func(Candidate) error) (Pod, error) {
+	if err := options.Validate(); err != nil {
+		return Pod{}, err
+	}
+	candidates := RankPools(ctx, pools, options)
+	if len(candidates) == 0 {
+		return Pod{}, fmt.Errorf("no pool can provide %d x %s", options.GPUCount, options.GPUModel)
+	}
+	var errs []error
+	for _, candidate := range candidates {
+		if err := ctx.Err(); err != nil {
+			return Pod{}, err
+		}
+		if onAttempt != nil {
+			if err := onAttempt(candidate); err != nil {
+				return Pod{}, err
+			}
+		}
+		pod, err := candidate.Pool.CreatePod(ctx, options)
+		if err == nil {
+			return pod, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", candidate.Pool.ID(), err))
+		if pod.ID != "" || !NothingCreated(err) {
+			return pod, errors.Join(errs...)
+		}
+	}
+	return Pod{}, errors.Join(errs...)
+}
@@ -0,0 +1,204 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+)
+
+// fakePool quotes fixed offers and fails CreatePod with createErr,
+// returning a pod with createdID if set
+type fakePool struct {
+	Pool
+	id        string
+	offers    []Offer
+	offersErr error
+	createErr error
+	createdID string
+	created   int
+}
+
+func (p *fakePool) ID() string {
+	return p.id
+}
+
+func (p *fakePool) ListOffers(ctx context.Context) ([]Offer, error) {
+	return p.offers, p.offersErr
+}
+
+func (p *fakePool) CreatePod(ctx context.Context, options PodOptions) (Pod, error) {
+	p.created++
+	if p.createErr != nil {
+		return Pod{ID: p.createdID}, p.createErr
+	}
+	return Pod{ID: p.id + "-pod", PoolID: p.id, Pool: p}, nil
+}
+
+func TestChooseOffer(t *testing.T) {
+	offers := []Offer{
+		{GPUModel: GPUModelRTX4090, DataCenterID: "1", DataCenterName: "Beijing", Stock: 8, PricePerHour: 2.0},
+		{GPUModel: GPUModelRTX4090, DataCenterID: "2", DataCenterName: "Shanghai", Stock: 2, PricePerHour: 1.5},
+		{GPUModel: GPUModelRTX4090, DataCenterID: "3", DataCenterName: "Chengdu", Stock: 4, PricePerHour: 1.8},
+		{GPUModel: GPUModelA100_80G, DataCenterID: "1", DataCenterName: "Beijing", Stock: 1, PricePerHour: 6.0},
+	}
+	tests := []struct {
+		name    string
+		options PodOptions
+		wantDC  string
+		wantErr bool
+	}{
+		{"cheapest", PodOptions{GPUModel: GPUModelRTX4090, GPUCount: 1}, "2", false},
+		{"cheapest with stock", PodOptions{GPUModel: GPUModelRTX4090, GPUCount: 4}, "3", false},
+		{"data center by id", PodOptions{GPUModel: GPUModelRTX4090, GPUCount: 1, DataCenter: "1"}, "1", false},
+		{"data center by name", PodOptions{GPUModel: GPUModelRTX4090, GPUCount: 1, DataCenter: "chengdu"}, "3", false},
+		{"data center without stock", PodOptions{GPUModel: GPUModelRTX4090, GPUCount: 4, DataCenter: "2"}, "", true},
+		{"preferred", PodOptions{GPUModel: GPUModelRTX4090, GPUCount: 1, PreferredDataCenters: []string{"Beijing"}}, "1", false},
+		{"first preferred with stock", PodOptions{GPUModel: GPUModelRTX4090, GPUCount: 4, PreferredDataCenters: []string{"2", "1"}}, "1", false},
+		{"preferred without stock falls back to cheapest", PodOptions{GPUModel: GPUModelRTX4090, GPUCount: 4, PreferredDataCenters: []string{"2"}}, "3", false},
+		{"data center wins over preferred", PodOptions{GPUModel: GPUModelRTX4090, GPUCount: 1, DataCenter: "3", PreferredDataCenters: []string{"1"}}, "3", false},
+		{"other model", PodOptions{GPUModel: GPUModelA100_80G, GPUCount: 1}, "1", false},
+		{"not enough stock", PodOptions{GPUModel: GPUModelA100_80G, GPUCount: 2}, "", true},
+		{"unknown model", PodOptions{GPUModel: GPUModelH100_80G, GPUCount: 1}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offer, err := ChooseOffer(offers, tt.options)
+			if tt.wantErr {
+				if !errors.Is(err, ErrOutOfStock) {
+					t.Errorf("ChooseOffer = %+v, %v, want %v", offer, err, ErrOutOfStock)
+				}
+				return
+			}
+			if err != nil || offer.DataCenterID != tt.wantDC || offer.GPUModel != tt.options.GPUModel {
+				t.Errorf("ChooseOffer = %+v, %v, want %s in data center %s", offer, err, tt.options.GPUModel, tt.wantDC)
+			}
+		})
+	}
+}
+
+func TestRankPools(t *testing.T) {
+	offer := func(price float64, stock int) []Offer {
+		return []Offer{{GPUModel: GPUModelRTX4090, Stock: stock, PricePerHour: price}}
+	}
+	tests := []struct {
+		name  string
+		pools []*fakePool
+		want  []string
+	}{
+		{
+			"cheapest first",
+			[]*fakePool{{id: "a", offers: offer(2, 8)}, {id: "b", offers: offer(1, 8)}, {id: "c", offers: offer(3, 8)}},
+			[]string{"b", "a", "c"},
+		},
+		{
+			"pools without stock are dropped",
+			[]*fakePool{{id: "a", offers: offer(2, 8)}, {id: "b", offers: offer(1, 1)}, {id: "c", offers: nil}},
+			[]string{"a"},
+		},
+		{
+			"unquoted pools last in their order",
+			[]*fakePool{{id: "a", offersErr: errors.New("down")}, {id: "b", offers: offer(3, 8)}, {id: "c", offersErr: errors.New("down")}},
+			[]string{"b", "a", "c"},
+		},
+		{
+			"equal prices keep their order",
+			[]*fakePool{{id: "a", offers: offer(2, 8)}, {id: "b", offers: offer(2, 8)}},
+			[]string{"a", "b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pools := make([]Pool, len(tt.pools))
+			for i, pool := range tt.pools {
+				pools[i] = pool
+			}
+			candidates := RankPools(context.Background(), pools, PodOptions{GPUModel: GPUModelRTX4090, GPUCount: 2})
+			got := make([]string, len(candidates))
+			for i, candidate := range candidates {
+				got[i] = candidate.Pool.ID()
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("RankPools = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// the quote is the price of the whole pod
+	pool := &fakePool{id: "a", offers: offer(1.5, 8)}
+	candidates := RankPools(context.Background(), []Pool{pool}, PodOptions{GPUModel: GPUModelRTX4090, GPUCount: 2})
+	if len(candidates) != 1 || !candidates[0].Quoted || candidates[0].PricePerHour != 3 {
+		t.Errorf("RankPools = %+v, want a quote of 3.00", candidates)
+	}
+}
+
+func TestPlacePod(t *testing.T) {
+	cheap := []Offer{{GPUModel: GPUModelRTX4090, Stock: 8, PricePerHour: 1}}
+	expensive := []Offer{{GPUModel: GPUModelRTX4090, Stock: 8, PricePerHour: 2}}
+	dialErr := fmt.Errorf("%w: %w", ErrTransient, &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED})
+	tests := []struct {
+		name       string
+		createErrs []error
+		wantPool   string
+		wantTried  int
+	}{
+		{"first succeeds", []error{nil, nil}, "a", 1},
+		{"out of stock falls back", []error{ErrOutOfStock, nil}, "b", 2},
+		{"rate limited falls back", []error{ErrRateLimited, nil}, "b", 2},
+		{"connection refused falls back", []error{dialErr, nil}, "b", 2},
+		{"timeout stops", []error{fmt.Errorf("%w: timeout", ErrTransient), nil}, "", 1},
+		{"unauthorized stops", []error{ErrUnauthorized, nil}, "", 1},
+		{"all fail", []error{ErrOutOfStock, ErrOutOfStock}, "", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &fakePool{id: "a", offers: cheap, createErr: tt.createErrs[0]}
+			b := &fakePool{id: "b", offers: expensive, createErr: tt.createErrs[1]}
+			pod, err := PlacePod(context.Background(), []Pool{a, b}, PodOptions{GPUModel: GPUModelRTX4090, GPUCount: 1}, nil)
+			if tt.wantPool == "" {
+				if err == nil {
+					t.Errorf("PlacePod created %s, want an error", pod.ID)
+				}
+			} else if err != nil || pod.PoolID != tt.wantPool {
+				t.Errorf("PlacePod = %q, %v, want a pod on %s", pod.PoolID, err, tt.wantPool)
+			}
+			if tried := a.created + b.created; tried != tt.wantTried {
+				t.Errorf("%d pools were asked to create the pod, want %d", tried, tt.wantTried)
+			}
+		})
+	}
+
+	t.Run("created pod that could not be fetched", func(t *testing.T) {
+		a := &fakePool{id: "a", offers: cheap, createErr: dialErr, createdID: "a-1"}
+		b := &fakePool{id: "b", offers: expensive}
+		pod, err := PlacePod(context.Background(), []Pool{a, b}, PodOptions{GPUModel: GPUModelRTX4090, GPUCount: 1}, nil)
+		if err == nil || pod.ID != "a-1" || b.created != 0 {
+			t.Errorf("PlacePod = %q, %v after %d creations on b, want a-1 and an error before trying b", pod.ID, err, b.created)
+		}
+	})
+
+	t.Run("abort before creating", func(t *testing.T) {
+		a := &fakePool{id: "a", offers: cheap}
+		abort := errors.New("aborted")
+		_, err := PlacePod(context.Background(), []Pool{a}, PodOptions{GPUModel: GPUModelRTX4090, GPUCount: 1}, func(Candidate) error {
+			return abort
+		})
+		if !errors.Is(err, abort) || a.created != 0 {
+			t.Errorf("PlacePod = %v after %d creations, want %v before any", err, a.created, abort)
+		}
+	})
+}
+
+func TestGetCreatedPod(t *testing.T) {
+	dialErr := fmt.Errorf("%w: %w", ErrTransient, &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED})
+	pool := &sequencePool{Pool: &fakePool{id: "a"}, pods: []Pod{{}}, errs: []error{dialErr}}
+	pod, err := GetCreatedPod(context.Background(), pool, "pod-1")
+	if err == nil || pod.ID != "pod-1" || pod.PoolID != "a" || pod.Pool != Pool(pool) {
+		t.Errorf("GetCreatedPod = %+v, %v, want pod-1 and an error", pod, err)
+	}
+	if NothingCreated(err) {
+		t.Errorf("NothingCreated(%v) = true for a created pod", err)
+	}
+}
@@ -1,6 +1,12 @@
 package internal
 
-import "fmt"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
 
 type PodStatus string
 
@@ -9,6 +15,8 @@ const (
 	StatusRunning  PodStatus = "running"
 	StatusStopped  PodStatus = "stopped"
 	StatusError    PodStatus = "error"
+	// StatusStopping is a pod shutting down, it becomes stopped
+	StatusStopping PodStatus = "stopping"
 )
 
 func CreatePodID(pool Pool, id string) string {
@@ -22,42 +30,172 @@ const (
 	GPUModelA100_80G  GPUModel = "A100-80G"
 	GPUModelA800_40G  GPUModel = "A800-40G"
 	GPUModelA800_80G  GPUModel = "A800-80G"
+	GPUModelH100_80G  GPUModel = "H100-80G"
+	GPUModelH800_80G  GPUModel = "H800-80G"
+	GPUModelL40S      GPUModel = "L40S"
 	GPUModelRTX4090   GPUModel = "RTX4090"
 	GPUModelRTX4090_D GPUModel = "RTX4090D"
+	GPUModelRTX4080   GPUModel = "RTX4080"
 	GPUModelRTX3090   GPUModel = "RTX3090"
 )
 
+// AutoShutdownAction is what happens to a pod when its auto-shutdown time is reached
+type AutoShutdownAction string
+
+const (
+	// AutoShutdownStop stops the pod and keeps its data, the disk is still billed
+	AutoShutdownStop AutoShutdownAction = "stop"
+	// AutoShutdownDestroy destroys the pod and its data
+	AutoShutdownDestroy AutoShutdownAction = "destroy"
+)
+
+// ParseAutoShutdownAction parses "stop" or "destroy"
+func ParseAutoShutdownAction(s string) (AutoShutdownAction, error) {
+	switch action := AutoShutdownAction(s); action {
+	case AutoShutdownStop, AutoShutdownDestroy:
+		return action, nil
+	default:
+		return "", fmt.Errorf("invalid auto-shutdown action %q, use stop or destroy", s)
+	}
+}
+
+// AutoShutdownPool is implemented by pools that can shut pods down
+// automatically, callers type-assert a Pool to find out. Pools implementing
+// it honor PodOptions.AutoShutdown in CreatePod.
+type AutoShutdownPool interface {
+	// SetAutoShutdown shuts the pod down after the given duration from now,
+	// zero turns auto-shutdown off
+	SetAutoShutdown(ctx context.Context, podID string, after time.Duration, action AutoShutdownAction) error
+}
+
 // PodOptions is the options for creating a pod
 type PodOptions struct {
 	GPUModel GPUModel
 	GPUCount int
+	// Name is the name of the pod, empty lets the pool choose one
+	Name string
+	// DataDiskSize is the size of the data disk in bytes, zero uses the
+	// default size of the pool
+	DataDiskSize int64
+	// Image is the ID or name of the image to create the pod from, empty
+	// uses the default image of the pool
+	Image string
+	// DataCenter is the ID or name of the data center the pod must be
+	// created in, empty lets the pool choose
+	DataCenter string
+	// PreferredDataCenters are tried in order when DataCenter is empty,
+	// before any other data center with stock
+	PreferredDataCenters []string
+	// AutoShutdown shuts the pod down this long after it is created, zero
+	// keeps it until it is destroyed
+	AutoShutdown       time.Duration
+	AutoShutdownAction AutoShutdownAction
 }
 
-// Pod represents a pod in a pool
+// podNamePattern is what pools accept as pod names
+var podNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,62}$`)
+
+// Validate checks the options before anything is created, so that a
+// mistake does not cost a paid pod
+func (o PodOptions) Validate() error {
+	if o.GPUModel == "" {
+		return errors.New("gpu model is required")
+	}
+	if o.GPUCount < 1 {
+		return fmt.Errorf("invalid gpu count %d, at least 1 is required", o.GPUCount)
+	}
+	if o.Name != "" && !podNamePattern.MatchString(o.Name) {
+		return fmt.Errorf("invalid pod name %q, use up to 63 letters, digits, dots, dashes and underscores", o.Name)
+	}
+	if o.DataDiskSize < 0 {
+		return fmt.Errorf("invalid data disk size %d", o.DataDiskSize)
+	}
+	if o.AutoShutdown < 0 {
+		return fmt.Errorf("invalid auto-shutdown %s", o.AutoShutdown)
+	}
+	if o.AutoShutdown > 0 && o.AutoShutdownAction != "" {
+		if _, err := ParseAutoShutdownAction(string(o.AutoShutdownAction)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PodOptionsChecker is implemented by pools that honor only some of the
+// PodOptions, callers type-assert a Pool to find out. Pools without it
+// honor all of them.
+type PodOptionsChecker interface {
+	// CheckPodOptions returns an error naming an option the pool would
+	// ignore
+	CheckPodOptions(options PodOptions) error
+}
+
+// CheckPodOptions returns an error if pool would ignore one of options, so
+// that no paid pod is created with the wrong configuration
+func CheckPodOptions(pool Pool, options PodOptions) error {
+	if options.AutoShutdown > 0 {
+		if _, ok := pool.(AutoShutdownPool); !ok {
+			return fmt.Errorf("pool %s does not support auto-shutdown", pool.ID())
+		}
+	}
+	if checker, ok := pool.(PodOptionsChecker); ok {
+		return checker.CheckPodOptions(options)
+	}
+	return nil
+}
+
+// Pod represents a pod in a pool, its JSON form is what gobun prints with
+// -o json and the Pool it belongs to is left out of it
 type Pod struct {
-	ID                     string
-	PoolID                 string
-	CreateTimestamp        int64
-	DataCenterName         string
-	Name                   string
-	GPUModel               GPUModel
-	GPUCount               int
-	CPUModel               string
-	CPUCoreCount           int
-	MemorySize             int64
-	SystemDiskSize         int64
-	DataDiskSize           int64
-	ExpandableDataDiskSize int64
-	DataDiskMountPath      string
-	PricePerHour           float64
-	SSHDomain              string
-	SSHKey                 string
-	SSHPort                string
-	SSHUser                string
-	Password               string
-	Status                 string
-	ImageID                string
-	ImageType              string
-	ImageSave              bool
-	Pool                   Pool
+	ID                     string    `json:"id"`
+	PoolID                 string    `json:"pool_id"`
+	CreateTimestamp        int64     `json:"create_timestamp"`
+	DataCenterName         string    `json:"data_center_name"`
+	Name                   string    `json:"name"`
+	GPUModel               GPUModel  `json:"gpu_model"`
+	GPUCount               int       `json:"gpu_count"`
+	CPUModel               string    `json:"cpu_model"`
+	CPUCoreCount           int       `json:"cpu_core_count"`
+	MemorySize             int64     `json:"memory_size"`
+	SystemDiskSize         int64     `json:"system_disk_size"`
+	DataDiskSize           int64     `json:"data_disk_size"`
+	ExpandableDataDiskSize int64     `json:"expandable_data_disk_size"`
+	DataDiskMountPath      string    `json:"data_disk_mount_path"`
+	PricePerHour           float64   `json:"price_per_hour"`
+	SSHDomain              string    `json:"ssh_domain"`
+	SSHKey                 string    `json:"ssh_key"`
+	SSHPort                string    `json:"ssh_port"`
+	SSHUser                string    `json:"ssh_user"`
+	Password               string    `json:"password"`
+	Status                 PodStatus `json:"status"`
+	// Progress is the provisioning progress in percent while the pod is
+	// creating, 0 when the pool does not report it
+	Progress  int    `json:"progress"`
+	ImageID   string `json:"image_id"`
+	ImageType string `json:"image_type"`
+	ImageSave bool   `json:"image_save"`
+	// WebURL is the console of the pod on the provider website
+	WebURL string `json:"web_url"`
+	// JupyterURL is where the provider exposes the Jupyter server of the pod,
+	// JupyterToken logs in to it
+	JupyterURL   string `json:"jupyter_url"`
+	JupyterToken string `json:"jupyter_token"`
+	// AutoShutdownTimestamp is when the pod is shut down automatically,
+	// zero when auto-shutdown is off
+	AutoShutdownTimestamp int64              `json:"auto_shutdown_timestamp"`
+	AutoShutdownAction    AutoShutdownAction `json:"auto_shutdown_action"`
+	Pool                  Pool               `json:"-"`
+}
+
+// AutoShutdownIn returns the time left until the pod is shut down
+// automatically, false when auto-shutdown is off
+func (p Pod) AutoShutdownIn(now time.Time) (time.Duration, bool) {
+	if p.AutoShutdownTimestamp == 0 {
+		return 0, false
+	}
+	left := time.Unix(p.AutoShutdownTimestamp, 0).Sub(now)
+	if left < 0 {
+		left = 0
+	}
+	return left, true
 }
@@ -1,17 +1,86 @@
 package internal
 
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
 // Pool represents an abstraction for cloud Pod management, for now we only support a could provider is a pool
+//
+// Every method that talks to the provider takes a context.Context, so that
+// callers can apply deadlines and cancel in-flight requests
 type Pool interface {
 	// Return the unique identifier of the pool
 	ID() string
 	// Returns the created Pod and any error encountered
-	CreatePod(PodOptions) (Pod, error)
+	CreatePod(ctx context.Context, options PodOptions) (Pod, error)
+
+	// GetPod returns the Pod with the given ID
+	// Returns the Pod and any error encountered
+	GetPod(ctx context.Context, PodID string) (Pod, error)
 
 	// DestroyPod removes a Pod from the cloud
 	// Takes a Pod ID and returns any error encountered
-	DestroyPod(PodID string) error
+	DestroyPod(ctx context.Context, PodID string) error
+
+	// StopPod shuts a Pod down without destroying it, so that the
+	// data disk and image are kept until the Pod is started again
+	StopPod(ctx context.Context, PodID string) error
+
+	// StartPod boots a stopped Pod
+	StartPod(ctx context.Context, PodID string) error
 
-	// ListPods returns a list of all Pods in the pool
+	// RestartPod reboots a running Pod
+	RestartPod(ctx context.Context, PodID string) error
+
+	// ListPods returns the Pods in the pool that match options
 	// Returns a slice of Pods and any error encountered
-	ListPods() ([]Pod, error)
+	ListPods(ctx context.Context, options ListOptions) ([]Pod, error)
+
+	// ListOffers returns the GPU configurations that can be created right now
+	// Returns a slice of Offers and any error encountered
+	ListOffers(ctx context.Context) ([]Offer, error)
+}
+
+// ListOptions filters the pods returned by ListPods, the zero value lists
+// all pods. Pools pass the filters their provider supports to its API and
+// apply the others with Match.
+type ListOptions struct {
+	// Statuses keeps the pods in one of these statuses, all if empty
+	Statuses []PodStatus
+	// Name keeps the pods whose name contains it
+	Name string
+}
+
+// Match reports whether a pod passes the filters
+func (o ListOptions) Match(pod Pod) bool {
+	if len(o.Statuses) > 0 && !slices.Contains(o.Statuses, pod.Status) {
+		return false
+	}
+	return strings.Contains(pod.Name, o.Name)
+}
+
+// FilterPods returns the pods that pass the filters of options
+func FilterPods(pods []Pod, options ListOptions) []Pod {
+	filtered := make([]Pod, 0, len(pods))
+	for _, pod := range pods {
+		if options.Match(pod) {
+			filtered = append(filtered, pod)
+		}
+	}
+	return filtered
+}
+
+// GetCreatedPod fetches a pod that CreatePod has just created. When that
+// fails the pod still exists and costs money, so the pod is returned with
+// its ID and the error does not wrap the cause, a failed dial of the fetch
+// must not read as NothingCreated.
+func GetCreatedPod(ctx context.Context, pool Pool, podID string) (Pod, error) {
+	pod, err := pool.GetPod(ctx, podID)
+	if err != nil {
+		return Pod{ID: podID, PoolID: pool.ID(), Pool: pool}, fmt.Errorf("pod %s was created but could not be fetched: %v", podID, err)
+	}
+	return pod, nil
 }
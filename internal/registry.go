@@ -0,0 +1,53 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// PoolFactory creates a Pool, adaptors read their own credentials in it
+type PoolFactory func() (Pool, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[string]PoolFactory)
+)
+
+// RegisterPool makes a pool provider available by name,
+// adaptors call it from init and it panics if the name is registered twice
+func RegisterPool(name string, factory PoolFactory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	if factory == nil {
+		panic("internal: RegisterPool factory is nil")
+	}
+	if _, dup := factories[name]; dup {
+		panic("internal: RegisterPool called twice for pool " + name)
+	}
+	factories[name] = factory
+}
+
+// NewPool creates the pool registered under the given name
+func NewPool(name string) (Pool, error) {
+	factoriesMu.RLock()
+	factory, ok := factories[name]
+	factoriesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown pool %q, available pools: %s", name, strings.Join(RegisteredPools(), ", "))
+	}
+	return factory()
+}
+
+// RegisteredPools returns the sorted names of all registered pools
+func RegisteredPools() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -1,6 +1,7 @@
 package ssh
 
 import (
+	"context"
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
@@ -16,11 +17,13 @@ import (
 	"golang.org/x/term"
 )
 
+// Client is a SSH client to a pod, the context passed to each method
+// cancels the session or the forwarding loop when it is done
 type Client interface {
-	Attach() error
-	ExecWithOutput(cmd string) ([]byte, error)
-	LocalForward(localAddress, targetAddress string) error
-	RemoteForward(localAddress, targetAddress string) error
+	Attach(ctx context.Context) error
+	ExecWithOutput(ctx context.Context, cmd string) ([]byte, error)
+	LocalForward(ctx context.Context, localAddress, targetAddress string) error
+	RemoteForward(ctx context.Context, localAddress, targetAddress string) error
 	Close() error
 }
 
@@ -61,7 +64,7 @@ type generalClient struct {
 	opt *Options
 }
 
-func NewClient(opt Options) (Client, error) {
+func NewClient(ctx context.Context, opt Options) (Client, error) {
 	logger := logrus.WithFields(logrus.Fields{
 		"user":             opt.User,
 		"port":             opt.Port,
@@ -106,11 +109,27 @@ func NewClient(opt Options) (Client, error) {
 
 	host := fmt.Sprintf("%s:%d", opt.Server, opt.Port)
 	// open connection
-	conn, err := ssh.Dial("tcp", host, config)
+	dialer := net.Dialer{}
+	netConn, err := dialer.DialContext(ctx, "tcp", host)
 	if err != nil {
 		return nil, errors.Wrap(err, "dialing failed")
 	}
-	cli = conn
+	// abort the handshake if the context is done before it completes
+	stopHandshake := context.AfterFunc(ctx, func() {
+		netConn.Close()
+	})
+	conn, chans, reqs, err := ssh.NewClientConn(netConn, host, config)
+	if !stopHandshake() {
+		if err == nil {
+			conn.Close()
+		}
+		return nil, errors.Wrap(ctx.Err(), "dialing failed")
+	}
+	if err != nil {
+		netConn.Close()
+		return nil, errors.Wrap(err, "dialing failed")
+	}
+	cli = ssh.NewClient(conn, chans, reqs)
 
 	if opt.AgentForwarding {
 		// open connection to the local agent
@@ -144,7 +163,7 @@ func (c generalClient) Close() error {
 	return c.cli.Close()
 }
 
-func (c generalClient) ExecWithOutput(cmd string) ([]byte, error) {
+func (c generalClient) ExecWithOutput(ctx context.Context, cmd string) ([]byte, error) {
 	defer c.cli.Close()
 
 	// open session
@@ -160,10 +179,19 @@ func (c generalClient) ExecWithOutput(cmd string) ([]byte, error) {
 		}
 	}
 
-	return session.CombinedOutput(cmd)
+	stop := context.AfterFunc(ctx, func() {
+		session.Close()
+	})
+	defer stop()
+
+	output, err := session.CombinedOutput(cmd)
+	if ctx.Err() != nil {
+		return output, ctx.Err()
+	}
+	return output, err
 }
 
-func (c generalClient) Attach() error {
+func (c generalClient) Attach(ctx context.Context) error {
 	// open session
 	session, err := c.cli.NewSession()
 	if err != nil {
@@ -229,6 +257,11 @@ func (c generalClient) Attach() error {
 	session.Stderr = os.Stderr
 	session.Stdin = os.Stdin
 
+	stop := context.AfterFunc(ctx, func() {
+		session.Close()
+	})
+	defer stop()
+
 	logger.Debug("starting shell")
 	err = session.Shell()
 	if err != nil {
@@ -236,6 +269,9 @@ func (c generalClient) Attach() error {
 	}
 	logger.Debug("waiting for shell to exit")
 	if err = session.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		var ee *ssh.ExitError
 		if ok := errors.As(err, &ee); ok {
 			switch ee.ExitStatus() {
@@ -257,11 +293,15 @@ func (c generalClient) Attach() error {
 	return nil
 }
 
-func (c generalClient) LocalForward(localAddress, targetAddress string) error {
+func (c generalClient) LocalForward(ctx context.Context, localAddress, targetAddress string) error {
 	localListener, err := net.Listen("tcp", localAddress)
 	if err != nil {
 		return errors.Wrap(err, "net.Listen failed")
 	}
+	stop := context.AfterFunc(ctx, func() {
+		localListener.Close()
+	})
+	defer stop()
 
 	logger := logrus.WithField("type", "local")
 
@@ -269,10 +309,13 @@ func (c generalClient) LocalForward(localAddress, targetAddress string) error {
 	for {
 		localCon, err := localListener.Accept()
 		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
 			return errors.Wrap(err, "listen.Accept failed")
 		}
 
-		sshConn, err := c.cli.Dial("tcp", targetAddress)
+		sshConn, err := c.cli.DialContext(ctx, "tcp", targetAddress)
 		if err != nil {
 			return errors.Wrap(err, "listen.Accept failed")
 		}
@@ -295,11 +338,15 @@ func (c generalClient) LocalForward(localAddress, targetAddress string) error {
 	}
 }
 
-func (c generalClient) RemoteForward(remoteAddress, targetAddress string) error {
+func (c generalClient) RemoteForward(ctx context.Context, remoteAddress, targetAddress string) error {
 	sshListener, err := c.cli.Listen("tcp", remoteAddress)
 	if err != nil {
 		return errors.Wrap(err, "cli.Listen failed")
 	}
+	stop := context.AfterFunc(ctx, func() {
+		sshListener.Close()
+	})
+	defer stop()
 
 	logger := logrus.WithField("type", "remote")
 
@@ -307,10 +354,14 @@ func (c generalClient) RemoteForward(remoteAddress, targetAddress string) error
 	for {
 		sshCon, err := sshListener.Accept()
 		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
 			return errors.Wrap(err, "listen.Accept failed")
 		}
 
-		targetCon, err := net.Dial("tcp", targetAddress)
+		var dialer net.Dialer
+		targetCon, err := dialer.DialContext(ctx, "tcp", targetAddress)
 		if err != nil {
 			return errors.Wrap(err, "net.Dial failed")
 		}
@@ -0,0 +1,33 @@
+package internal
+
+import "context"
+
+// Object is a file in object storage
+type Object struct {
+	Key  string
+	Size int64
+	// LastModified is a Unix timestamp
+	LastModified int64
+}
+
+// Progress reports the bytes transferred so far out of total
+type Progress func(done int64, total int64)
+
+// Storage is an object storage that outlives pods, keys are slash-separated paths
+type Storage interface {
+	// List returns the objects whose key starts with prefix
+	List(ctx context.Context, prefix string) ([]Object, error)
+	// Upload copies a local file to key, an interrupted upload of the same
+	// file to the same key resumes where it stopped
+	Upload(ctx context.Context, localPath string, key string, progress Progress) error
+	// Download copies key to a local file, an interrupted download resumes
+	// where it stopped
+	Download(ctx context.Context, key string, localPath string, progress Progress) error
+	Remove(ctx context.Context, key string) error
+}
+
+// StoragePool is implemented by pools with object storage, callers
+// type-assert a Pool to find out
+type StoragePool interface {
+	Storage(ctx context.Context) (Storage, error)
+}
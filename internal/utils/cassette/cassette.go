@@ -0,0 +1,197 @@
+// Package cassette records HTTP interactions with a provider API to a JSON
+// file and replays them later, so real API traffic captured once can drive
+// tests and bug reports without credentials or network access.
+//
+// Credentials never reach the file: authorization headers, cookies and
+// JSON fields or query parameters that look like tokens, passwords, keys or
+// secrets are replaced with Redacted before an interaction is written.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Redacted replaces sanitized values
+const Redacted = "REDACTED"
+
+// Cassette is the file format, a list of interactions in the order they happened
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a request and the response it got
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Recorder is a RoundTripper that sends requests with the next RoundTripper
+// and appends every sanitized interaction to a cassette file
+type Recorder struct {
+	file string
+	next http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder records to file, which is rewritten after every interaction,
+// a nil next uses http.DefaultTransport
+func NewRecorder(file string, next http.RoundTripper) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Recorder{file: file, next: next}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := readBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	respHeader := sanitizeHeader(resp.Header)
+	// The sanitized body may differ in length from the one received
+	respHeader.Del("Content-Length")
+
+	interaction := Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    sanitizeURL(req.URL.String()),
+			Header: sanitizeHeader(req.Header),
+			Body:   sanitizeBody(reqBody),
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     respHeader,
+			Body:       sanitizeBody(respBody),
+		},
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	if err := save(r.file, &r.cassette); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Player is a RoundTripper that answers requests from a cassette. Each
+// request gets the first unused interaction with the same method and URL,
+// so repeated calls such as polling replay in recorded order.
+type Player struct {
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// Load reads a cassette file for replay
+func Load(file string) (*Player, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read cassette")
+	}
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse cassette %s", file)
+	}
+	return NewPlayer(cassette), nil
+}
+
+func NewPlayer(cassette Cassette) *Player {
+	return &Player{
+		interactions: cassette.Interactions,
+		used:         make([]bool, len(cassette.Interactions)),
+	}
+}
+
+func (p *Player) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	target := sanitizeURL(req.URL.String())
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, interaction := range p.interactions {
+		if p.used[i] || interaction.Request.Method != req.Method || interaction.Request.URL != target {
+			continue
+		}
+		p.used[i] = true
+		header := interaction.Response.Header.Clone()
+		if header == nil {
+			header = http.Header{}
+		}
+		return &http.Response{
+			Status:        http.StatusText(interaction.Response.StatusCode),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, errors.Newf("cassette has no unused interaction for %s %s", req.Method, target)
+}
+
+// readBody reads a body and replaces it with an unread copy
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	data, err := io.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return nil, err
+	}
+	*body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+func save(file string, cassette *Cassette) error {
+	data, err := json.MarshalIndent(cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return errors.Wrap(err, "failed to create cassette directory")
+	}
+	if err := os.WriteFile(file, data, 0600); err != nil {
+		return errors.Wrap(err, "failed to write cassette")
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// sensitiveHeaders are always redacted
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// sensitiveWords mark header, JSON field and query parameter names whose
+// values are redacted, compared case-insensitively as substrings
+var sensitiveWords = []string{"token", "password", "passwd", "secret", "ssh_key", "sshkey", "api_key", "apikey", "authorization", "credential"}
+
+func isSensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, word := range sensitiveWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+func sanitizeHeader(header http.Header) http.Header {
+	if len(header) == 0 {
+		return nil
+	}
+	sanitized := header.Clone()
+	for _, name := range sensitiveHeaders {
+		if sanitized.Get(name) != "" {
+			sanitized.Set(name, Redacted)
+		}
+	}
+	for name := range sanitized {
+		if isSensitive(name) {
+			sanitized[name] = []string{Redacted}
+		}
+	}
+	return sanitized
+}
+
+// sanitizeURL redacts sensitive query parameters and user info
+func sanitizeURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || (u.RawQuery == "" && u.User == nil) {
+		return raw
+	}
+	if u.User != nil {
+		u.User = url.User(Redacted)
+	}
+	query := u.Query()
+	changed := false
+	for name := range query {
+		if isSensitive(name) {
+			query[name] = []string{Redacted}
+			changed = true
+		}
+	}
+	if changed {
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
+
+// sanitizeBody redacts sensitive fields of JSON bodies, other bodies cannot
+// be inspected and are replaced with Redacted as a whole
+func sanitizeBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	// Large integer IDs would lose digits as float64
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return Redacted
+	}
+	sanitized, err := json.Marshal(sanitizeValue(value))
+	if err != nil {
+		return Redacted
+	}
+	return string(sanitized)
+}
+
+func sanitizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if isSensitive(key) && isScalar(field) {
+				v[key] = Redacted
+			} else {
+				v[key] = sanitizeValue(field)
+			}
+		}
+		return v
+	case []interface{}:
+		for i := range v {
+			v[i] = sanitizeValue(v[i])
+		}
+		return v
+	case string:
+		// URLs such as jupyter_url carry tokens in their query
+		if strings.HasPrefix(v, "http://") || strings.HasPrefix(v, "https://") {
+			return sanitizeURL(v)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// isScalar reports whether a JSON value is a non-empty string, number or bool
+func isScalar(value interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		return v != ""
+	case json.Number, bool:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,127 @@
+// Package jsonpath looks up values in decoded JSON documents with simple
+// dotted paths such as "data.list[0].id", GetAll also expands "list[*].id".
+package jsonpath
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Get returns the value at path in data, which must be decoded into
+// interface{} values by encoding/json. An empty path, "$" or "." returns
+// data itself. It reports false if any step of the path is missing.
+func Get(data interface{}, path string) (interface{}, bool, error) {
+	steps, err := Parse(path)
+	if err != nil {
+		return nil, false, err
+	}
+	current := data
+	for _, step := range steps {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			if step.Key == "" {
+				return nil, false, nil
+			}
+			value, ok := node[step.Key]
+			if !ok {
+				return nil, false, nil
+			}
+			current = value
+		case []interface{}:
+			if step.Key != "" || step.All || step.Index < 0 || step.Index >= len(node) {
+				return nil, false, nil
+			}
+			current = node[step.Index]
+		default:
+			return nil, false, nil
+		}
+	}
+	return current, true, nil
+}
+
+// GetAll returns the values at path in data like Get, a [*] step expands
+// to every element of an array. Missing values are left out.
+func GetAll(data interface{}, path string) ([]interface{}, error) {
+	steps, err := Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	return getAll(data, steps), nil
+}
+
+func getAll(current interface{}, steps []Step) []interface{} {
+	if len(steps) == 0 {
+		return []interface{}{current}
+	}
+	step := steps[0]
+	switch node := current.(type) {
+	case map[string]interface{}:
+		if value, ok := node[step.Key]; ok && step.Key != "" {
+			return getAll(value, steps[1:])
+		}
+	case []interface{}:
+		if step.All {
+			var values []interface{}
+			for _, element := range node {
+				values = append(values, getAll(element, steps[1:])...)
+			}
+			return values
+		}
+		if step.Key == "" && step.Index >= 0 && step.Index < len(node) {
+			return getAll(node[step.Index], steps[1:])
+		}
+	}
+	return nil
+}
+
+// Step is one step of a path, either a Key of an object, an Index of an
+// array or All elements of an array
+type Step struct {
+	Key   string
+	Index int
+	All   bool
+}
+
+// Parse splits a path like "data.list[0].id" into steps
+func Parse(path string) ([]Step, error) {
+	path = strings.TrimPrefix(path, "$")
+	path = strings.TrimPrefix(path, ".")
+	var steps []Step
+	if path == "" {
+		return steps, nil
+	}
+	for _, part := range strings.Split(path, ".") {
+		key := part
+		var indexes []Step
+		if i := strings.IndexByte(part, '['); i >= 0 {
+			key = part[:i]
+			rest := part[i:]
+			for rest != "" {
+				end := strings.IndexByte(rest, ']')
+				if rest[0] != '[' || end < 0 {
+					return nil, errors.Newf("invalid path %q", path)
+				}
+				if rest[1:end] == "*" {
+					indexes = append(indexes, Step{All: true})
+				} else {
+					index, err := strconv.Atoi(rest[1:end])
+					if err != nil {
+						return nil, errors.Newf("invalid index in path %q", path)
+					}
+					indexes = append(indexes, Step{Index: index})
+				}
+				rest = rest[end+1:]
+			}
+		}
+		if key == "" && len(indexes) == 0 {
+			return nil, errors.Newf("invalid path %q", path)
+		}
+		if key != "" {
+			steps = append(steps, Step{Key: key})
+		}
+		steps = append(steps, indexes...)
+	}
+	return steps, nil
+}
@@ -0,0 +1,89 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    []Step
+		wantErr bool
+	}{
+		{path: "", want: nil},
+		{path: "$", want: nil},
+		{path: ".", want: nil},
+		{path: "id", want: []Step{{Key: "id"}}},
+		{path: "$.data.id", want: []Step{{Key: "data"}, {Key: "id"}}},
+		{path: ".data.id", want: []Step{{Key: "data"}, {Key: "id"}}},
+		{path: "data.list[0].id", want: []Step{{Key: "data"}, {Key: "list"}, {Index: 0}, {Key: "id"}}},
+		{path: "[*].id", want: []Step{{All: true}, {Key: "id"}}},
+		{path: "grid[1][2]", want: []Step{{Key: "grid"}, {Index: 1}, {Index: 2}}},
+		{path: "data..id", wantErr: true},
+		{path: "list[", wantErr: true},
+		{path: "list[a]", wantErr: true},
+		{path: "list]0[", wantErr: true},
+		{path: "list[0]x", wantErr: true},
+	}
+	for _, tt := range tests {
+		steps, err := Parse(tt.path)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Parse(%q) = %+v, want an error", tt.path, steps)
+			}
+			continue
+		}
+		if err != nil || len(steps) != len(tt.want) || (len(steps) > 0 && !reflect.DeepEqual(steps, tt.want)) {
+			t.Errorf("Parse(%q) = %+v, %v, want %+v", tt.path, steps, err, tt.want)
+		}
+	}
+}
+
+func TestGetAll(t *testing.T) {
+	var data interface{}
+	err := json.Unmarshal([]byte(`[
+		{"id": "a", "status": "running", "gpus": [{"model": "RTX4090"}, {"model": "RTX4090"}]},
+		{"id": "b", "status": "stopped", "gpus": []},
+		{"id": "c", "gpus": [{"model": "A100-80G"}]}
+	]`), &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{path: "[*].id", want: "[a b c]"},
+		{path: "[*].status", want: "[running stopped]"},
+		{path: "[1].id", want: "[b]"},
+		{path: "[*].gpus[*].model", want: "[RTX4090 RTX4090 A100-80G]"},
+		{path: "[*].gpus[0].model", want: "[RTX4090 A100-80G]"},
+		{path: "[5].id", want: "[]"},
+		{path: "[-1].id", want: "[]"},
+		{path: "id", want: "[]"},
+		{path: "[*].missing", want: "[]"},
+		{path: "[*]..id", wantErr: true},
+	}
+	for _, tt := range tests {
+		values, err := GetAll(data, tt.path)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("GetAll(%q) = %v, want an error", tt.path, values)
+			}
+			continue
+		}
+		if got := fmt.Sprint(values); err != nil || got != tt.want {
+			t.Errorf("GetAll(%q) = %s, %v, want %s", tt.path, got, err, tt.want)
+		}
+	}
+
+	// an empty path returns the document itself
+	values, err := GetAll(data, "")
+	if err != nil || len(values) != 1 || !reflect.DeepEqual(values[0], data) {
+		t.Errorf("GetAll of the empty path = %v, %v, want the document", values, err)
+	}
+}
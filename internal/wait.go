@@ -0,0 +1,107 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultWaitInterval is how often a Waiter polls the pod
+const DefaultWaitInterval = 5 * time.Second
+
+// WaitProgress is the state of a pod that is being waited for
+type WaitProgress struct {
+	Pod     Pod
+	Elapsed time.Duration
+	// Remaining is the estimated time until the pod is running, valid if
+	// Estimated is true
+	Remaining time.Duration
+	Estimated bool
+}
+
+// Waiter polls a pod until it is running
+type Waiter struct {
+	// Interval is the time between polls, DefaultWaitInterval if zero
+	Interval time.Duration
+	// OnProgress is called with the pod as it was created and after every poll
+	OnProgress func(WaitProgress)
+}
+
+// Wait polls the pool of pod until the pod is running and returns it. It
+// fails if the pod ends up in the error status, is stopped, e.g. by an
+// auto-shutdown, or disappears, retryable errors of the pool are logged and
+// polled again.
+func (w Waiter) Wait(ctx context.Context, pod Pod) (Pod, error) {
+	interval := w.Interval
+	if interval == 0 {
+		interval = DefaultWaitInterval
+	}
+	start := time.Now()
+	estimator := newProgressEstimator(start, pod.Progress)
+	report := func() {
+		if w.OnProgress == nil {
+			return
+		}
+		now := time.Now()
+		remaining, ok := estimator.remaining(now, pod.Progress)
+		w.OnProgress(WaitProgress{
+			Pod:       pod,
+			Elapsed:   now.Sub(start),
+			Remaining: remaining,
+			Estimated: ok,
+		})
+	}
+	report()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for pod.Status != StatusRunning {
+		switch pod.Status {
+		case StatusError:
+			return pod, fmt.Errorf("pod %s failed to start", pod.ID)
+		case StatusStopping, StatusStopped:
+			return pod, fmt.Errorf("pod %s is %s instead of starting", pod.ID, pod.Status)
+		}
+		select {
+		case <-ctx.Done():
+			return pod, ctx.Err()
+		case <-ticker.C:
+		}
+
+		current, err := pod.Pool.GetPod(ctx, pod.ID)
+		if IsRetryable(err) {
+			logrus.Warnf("Failed to get pod status, retrying: %v", err)
+			continue
+		}
+		if err != nil {
+			return pod, fmt.Errorf("failed to get pod status: %w", err)
+		}
+		pod = current
+		report()
+	}
+	return pod, nil
+}
+
+// progressEstimator extrapolates the provisioning progress linearly from
+// the first progress it saw
+type progressEstimator struct {
+	start         time.Time
+	startProgress int
+}
+
+func newProgressEstimator(start time.Time, progress int) progressEstimator {
+	return progressEstimator{start: start, startProgress: progress}
+}
+
+// remaining returns the estimated time until progress reaches 100, false
+// until the progress has moved
+func (e progressEstimator) remaining(now time.Time, progress int) (time.Duration, bool) {
+	if progress <= e.startProgress || progress >= 100 {
+		return 0, progress >= 100
+	}
+	elapsed := now.Sub(e.start)
+	rate := float64(progress-e.startProgress) / elapsed.Seconds()
+	return time.Duration(float64(100-progress) / rate * float64(time.Second)), true
+}
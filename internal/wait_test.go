@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestProgressEstimator(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	tests := []struct {
+		name          string
+		startProgress int
+		elapsed       time.Duration
+		progress      int
+		want          time.Duration
+		estimated     bool
+	}{
+		{"no progress yet", 0, 10 * time.Second, 0, 0, false},
+		{"progress went back", 40, 10 * time.Second, 30, 0, false},
+		{"quarter in 10s", 0, 10 * time.Second, 25, 30 * time.Second, true},
+		{"half in a minute", 0, time.Minute, 50, time.Minute, true},
+		{"from a started pod", 40, 20 * time.Second, 60, 40 * time.Second, true},
+		{"done", 0, time.Minute, 100, 0, true},
+		{"done from the start", 100, 0, 100, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			estimator := newProgressEstimator(start, tt.startProgress)
+			got, ok := estimator.remaining(start.Add(tt.elapsed), tt.progress)
+			if ok != tt.estimated || (ok && (got-tt.want).Abs() > time.Millisecond) {
+				t.Errorf("remaining after %s at %d%% = %s, %v, want %s, %v", tt.elapsed, tt.progress, got, ok, tt.want, tt.estimated)
+			}
+		})
+	}
+}
+
+// sequencePool returns the pods of a sequence from GetPod, one per call,
+// and the last one again once the sequence is used up
+type sequencePool struct {
+	Pool
+	pods []Pod
+	errs []error
+	call int
+}
+
+func (p *sequencePool) GetPod(ctx context.Context, podID string) (Pod, error) {
+	i := min(p.call, len(p.pods)-1)
+	p.call++
+	var err error
+	if i < len(p.errs) {
+		err = p.errs[i]
+	}
+	pod := p.pods[i]
+	pod.Pool = p
+	return pod, err
+}
+
+func TestWaiter(t *testing.T) {
+	creating := func(progress int) Pod {
+		return Pod{ID: "pod", Status: StatusCreating, Progress: progress}
+	}
+	tests := []struct {
+		name    string
+		pods    []Pod
+		errs    []error
+		want    PodStatus
+		wantErr bool
+	}{
+		{"becomes running", []Pod{creating(30), creating(60), {ID: "pod", Status: StatusRunning}}, nil, StatusRunning, false},
+		{"retries transient errors", []Pod{{}, {ID: "pod", Status: StatusRunning}}, []error{fmt.Errorf("%w: timeout", ErrTransient)}, StatusRunning, false},
+		{"fails on error status", []Pod{creating(30), {ID: "pod", Status: StatusError}}, nil, StatusError, true},
+		{"fails when the pod stops", []Pod{creating(30), {ID: "pod", Status: StatusStopped}}, nil, StatusStopped, true},
+		{"fails when the pod is stopping", []Pod{creating(30), {ID: "pod", Status: StatusStopping}}, nil, StatusStopping, true},
+		{"fails when the pod is gone", []Pod{{}}, []error{ErrPodNotFound}, StatusCreating, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := &sequencePool{pods: tt.pods, errs: tt.errs}
+			var reports []WaitProgress
+			waiter := Waiter{
+				Interval:   time.Millisecond,
+				OnProgress: func(progress WaitProgress) { reports = append(reports, progress) },
+			}
+			pod, err := waiter.Wait(context.Background(), Pod{ID: "pod", Status: StatusCreating, Pool: pool})
+			if (err != nil) != tt.wantErr || pod.Status != tt.want {
+				t.Errorf("Wait = %s, %v, want %s and error %v", pod.Status, err, tt.want, tt.wantErr)
+			}
+			if len(reports) == 0 || reports[0].Pod.Progress != 0 {
+				t.Errorf("the pod as created was not reported first: %+v", reports)
+			}
+		})
+	}
+
+	t.Run("context cancelled", func(t *testing.T) {
+		pool := &sequencePool{pods: []Pod{creating(10)}}
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_, err := Waiter{Interval: time.Millisecond}.Wait(ctx, Pod{ID: "pod", Status: StatusCreating, Pool: pool})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Wait = %v, want %v", err, context.DeadlineExceeded)
+		}
+	})
+}